@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bookland/internal/admin"
+	"bookland/internal/db"
+	"bookland/internal/store"
+	"fmt"
+	"strings"
+)
+
+// adminCommand runs "<resource> <action> [flags] [args]" against the
+// configured database.
+func adminCommand(resource string) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("usage: %s <%s> [flags] [args]", resource, strings.Join(admin.Actions, "|"))
+		}
+
+		fs := newFlagSet(resource + " " + args[0])
+		cmd, err := admin.New(resource, args[0], fs)
+		if err != nil {
+			return err
+		}
+		cfg, rest, err := loadConfig(fs, args[1:])
+		if err != nil {
+			return err
+		}
+
+		conn, err := db.Open(cfg.DB)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		s := store.NewStoreFor(conn, cfg.DB.Driver).WithNameFormat(cfg.NameFormat)
+		if err := fillSlugs(s); err != nil {
+			return err
+		}
+		cmd.Names = cfg.NameFormat
+		return cmd.Run(s, rest)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bookland/internal/backup"
+	"bookland/internal/config"
+	"bookland/internal/db"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// takeBackup writes a snapshot and deletes the ones beyond cfg.Keep.
+func takeBackup(conn *sql.DB, cfg config.Backup) error {
+	snapshot, err := backup.Create(conn, cfg.Dir)
+	if err != nil {
+		return err
+	}
+	log.Printf("backup: wrote %s (%d bytes)\n", snapshot.Path, snapshot.Size)
+
+	removed, err := backup.Rotate(cfg.Dir, cfg.Keep)
+	if err != nil {
+		return err
+	}
+	for _, s := range removed {
+		log.Printf("backup: removed %s\n", s.Path)
+	}
+	return nil
+}
+
+// scheduleBackups takes a backup every cfg.Interval while the server runs.
+// A failed backup is logged and retried at the next tick.
+func scheduleBackups(conn *sql.DB, cfg config.Backup) {
+	for range time.Tick(cfg.Interval) {
+		if err := takeBackup(conn, cfg); err != nil {
+			log.Printf("backup: %s\n", err)
+		}
+	}
+}
+
+// sqlitePath returns the file name in a SQLite DSN such as
+// "file:book.db?cache=shared".
+func sqlitePath(dsn string) string {
+	return strings.TrimPrefix(strings.SplitN(dsn, "?", 2)[0], "file:")
+}
+
+func requireSQLite(cfg config.Config) error {
+	if cfg.DB.Driver != db.DriverSQLite {
+		return fmt.Errorf("backups are only supported for %s", db.DriverSQLite)
+	}
+	return nil
+}
+
+func backupCommand(args []string) error {
+	cfg, _, err := loadConfig(newFlagSet("backup"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireSQLite(cfg); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return takeBackup(conn, cfg.Backup)
+}
+
+func snapshotsCommand(args []string) error {
+	cfg, _, err := loadConfig(newFlagSet("snapshots"), args)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := backup.List(cfg.Backup.Dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		fmt.Printf("%s\t%s\t%d\n", s.Name, s.CreatedAt.Local().Format(time.RFC3339), s.Size)
+	}
+	return nil
+}
+
+func restoreCommand(args []string) error {
+	cfg, rest, err := loadConfig(newFlagSet("restore"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireSQLite(cfg); err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("usage: restore [flags] <snapshot>, see the snapshots command")
+	}
+
+	snapshot, err := backup.Find(cfg.Backup.Dir, rest[0])
+	if err != nil {
+		return err
+	}
+	target := sqlitePath(cfg.DB.DSN)
+	if err := backup.Restore(snapshot, target); err != nil {
+		return err
+	}
+	log.Printf("restore: replaced %s with %s, the old database is kept as %s.before-restore\n", target, snapshot.Path, target)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/loadtest"
+	"bookland/internal/store"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loadtestCommand measures the repository methods against a generated
+// catalogue in a temporary SQLite database, or with -books 0 against the
+// configured database, and optionally compares the result with a baseline.
+func loadtestCommand(args []string) error {
+	fs := newFlagSet("loadtest")
+	var gen fixture.GenerateOptions
+	var o loadtest.Options
+	var ops, reportPath, baselinePath string
+	var threshold float64
+	fs.IntVar(&gen.Books, "books", 10000, "books to generate into a temporary database, 0 to query the configured one")
+	fs.IntVar(&gen.Authors, "authors", 0, "authors to generate, one per ten books by default")
+	fs.Int64Var(&o.Seed, "seed", 1, "random seed of the catalogue and the queries")
+	fs.IntVar(&o.Iterations, "iterations", 200, "calls per operation")
+	fs.IntVar(&o.Concurrency, "concurrency", 1, "goroutines calling an operation at once")
+	fs.StringVar(&ops, "ops", "", "comma separated operations to measure, all by default")
+	fs.StringVar(&reportPath, "report", "", "write the report as JSON to this file")
+	fs.StringVar(&baselinePath, "baseline", "", "compare with the JSON report of an earlier run and fail on regressions")
+	fs.Float64Var(&threshold, "threshold", 0.2, "slowdown of P50 or P95 counted as a regression, as a fraction")
+	cfg, _, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	gen.Seed = o.Seed
+	if ops != "" {
+		o.Operations = strings.Split(ops, ",")
+	}
+
+	var baseline *loadtest.Report
+	if baselinePath != "" {
+		if baseline, err = loadtest.ReadFile(baselinePath); err != nil {
+			return err
+		}
+	}
+
+	var conn *sql.DB
+	if gen.Books > 0 {
+		dir, err := ioutil.TempDir("", "bookland-loadtest-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		cfg.DB.DSN = filepath.Join(dir, "loadtest.db")
+		log.Printf("loadtest: generating %d books into %s\n", gen.Books, cfg.DB.DSN)
+		start := time.Now()
+		if conn, err = loadtest.Create(cfg.DB, gen); err != nil {
+			return err
+		}
+		log.Printf("loadtest: generated in %s\n", time.Since(start).Round(time.Millisecond))
+	} else if conn, err = db.Open(cfg.DB); err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	report, err := loadtest.Run(store.NewStoreFor(conn, cfg.DB.Driver).WithNameFormat(cfg.NameFormat), o)
+	if err != nil {
+		return err
+	}
+	report.Driver = cfg.DB.Driver
+	if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+
+	if reportPath != "" {
+		f, err := os.Create(reportPath)
+		if err != nil {
+			return err
+		}
+		if err := report.WriteJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	if baseline != nil {
+		fmt.Println()
+		comparison := loadtest.Compare(baseline, report, threshold)
+		if err := comparison.WriteText(os.Stdout); err != nil {
+			return err
+		}
+		if regressions := comparison.Regressions(); len(regressions) > 0 {
+			return fmt.Errorf("loadtest: %d operations regressed against %s", len(regressions), baselinePath)
+		}
+	}
+	return nil
+}
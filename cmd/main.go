@@ -1,20 +1,150 @@
 package main
 
 import (
+	"bookland/internal/admin"
+	"bookland/internal/blob"
+	"bookland/internal/config"
 	"bookland/internal/db"
+	"bookland/internal/poster"
+	"bookland/internal/server"
 	"bookland/internal/store"
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"strings"
 )
 
 func init() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 }
 
+// command is a subcommand of the binary. run gets the arguments after the
+// command name.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "serve [flags]: run the HTTP server (the default)", serve},
+		{"backup", "backup [flags]: take a snapshot of the SQLite database and rotate old ones", backupCommand},
+		{"snapshots", "snapshots [flags]: list the snapshots in the backup directory", snapshotsCommand},
+		{"restore", "restore [flags] <snapshot>: replace the SQLite database with a snapshot; stop the server first", restoreCommand},
+		{"purge", "purge [flags]: permanently remove what has been in the trash longer than -trash-retention, and orphaned poster files", purgeCommand},
+		{"seed", "seed [flags] [file...]: load YAML or JSON fixtures and generated books into the database", seedCommand},
+		{"loadtest", "loadtest [flags]: measure repository latencies on a generated catalogue and compare with a baseline", loadtestCommand},
+		{"books", "books <action> [flags] [args]: manage books, see below", adminCommand("books")},
+		{"authors", "authors <action> [flags] [args]: manage authors", adminCommand("authors")},
+		{"genres", "genres <action> [flags] [args]: manage genres", adminCommand("genres")},
+	}
+}
+
 func main() {
-	conn, err := db.NewSQLiteDB("book.db")
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				log.Fatalf("%s\n", err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q, want one of:\n", name)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
+	}
+	fmt.Fprintf(os.Stderr, "actions: %s\n", strings.Join(admin.Actions, ", "))
+	os.Exit(2)
+}
+
+// loadConfig reads the configuration with the flags in args and returns the
+// arguments left after the flags. Commands may add their own flags to fs.
+func loadConfig(fs *flag.FlagSet, args []string) (config.Config, []string, error) {
+	cfg, err := config.Load(fs, args, os.Getenv)
+	return cfg, fs.Args(), err
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ExitOnError)
+}
+
+func serve(args []string) error {
+	cfg, _, err := loadConfig(newFlagSet("serve"), args)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(cfg.DB)
 	if err != nil {
-		log.Fatalf("%s\n", err)
+		return err
 	}
 
-	_ = store.NewStore(conn)
+	settings, err := db.Describe(conn, cfg.DB)
+	if err != nil {
+		return err
+	}
+	log.Printf("database: %s\n", settings)
+
+	s := store.NewStoreFor(conn, cfg.DB.Driver).WithNameFormat(cfg.NameFormat)
+
+	// Authors stored before slugs existed get theirs before the first request.
+	if err := fillSlugs(s); err != nil {
+		return err
+	}
+
+	media, err := blob.NewLocalStore(cfg.MediaDir, cfg.MediaURL)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Backup.Interval > 0 {
+		if err := requireSQLite(cfg); err != nil {
+			return err
+		}
+		go scheduleBackups(conn, cfg.Backup)
+	}
+
+	posters := poster.New(s, media)
+	if cfg.Trash.PurgeInterval > 0 {
+		go schedulePurges(posters.WithStore(s.WithActor("purge")), cfg.Trash)
+	}
+
+	mediaPrefix := strings.TrimSuffix(cfg.MediaURL, "/") + "/"
+	mux := http.NewServeMux()
+	mux.Handle(mediaPrefix, http.StripPrefix(mediaPrefix, media.Handler()))
+	srv := server.New(s).
+		WithPosters(posters).
+		WithHealthCheck(func(ctx context.Context) error { return db.HealthCheck(ctx, conn) })
+	if cfg.TrustActorHeader {
+		srv = srv.WithTrustedActorHeader()
+	}
+	mux.Handle("/", srv)
+
+	log.Printf("listening on %s\n", cfg.Addr)
+	return http.ListenAndServe(cfg.Addr, mux)
+}
+
+// fillSlugs derives the slugs of authors stored before slugs existed, so
+// they can be looked up by slug.
+func fillSlugs(s *store.Store) error {
+	filled, err := s.Authors.FillSlugs()
+	if err != nil {
+		return err
+	}
+	if filled > 0 {
+		log.Printf("derived slugs of %d authors\n", filled)
+	}
+	return nil
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bookland/internal/blob"
+	"bookland/internal/config"
+	"bookland/internal/db"
+	"bookland/internal/poster"
+	"bookland/internal/store"
+	"log"
+	"time"
+)
+
+// purgeTrash permanently removes what has been in the trash for longer than
+// cfg.Retention, together with the poster files no book refers to.
+func purgeTrash(p *poster.Posters, cfg config.Trash) error {
+	books, authors, files, err := p.Purge(cfg.Retention)
+	if err != nil {
+		return err
+	}
+	log.Printf("purge: removed %d books, %d authors and %d poster files\n", books, authors, files)
+	return nil
+}
+
+// schedulePurges purges the trash every cfg.PurgeInterval while the server
+// runs. A failed purge is logged and retried at the next tick.
+func schedulePurges(p *poster.Posters, cfg config.Trash) {
+	for range time.Tick(cfg.PurgeInterval) {
+		if err := purgeTrash(p, cfg); err != nil {
+			log.Printf("purge: %s\n", err)
+		}
+	}
+}
+
+func purgeCommand(args []string) error {
+	cfg, _, err := loadConfig(newFlagSet("purge"), args)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	media, err := blob.NewLocalStore(cfg.MediaDir, cfg.MediaURL)
+	if err != nil {
+		return err
+	}
+	s := store.NewStoreFor(conn, cfg.DB.Driver).WithActor("purge")
+	return purgeTrash(poster.New(s, media), cfg.Trash)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/store"
+	"fmt"
+	"log"
+)
+
+// seedCommand loads fixture files and, with -generate, a synthetic
+// catalogue into the configured database.
+func seedCommand(args []string) error {
+	fs := newFlagSet("seed")
+	var o fixture.GenerateOptions
+	fs.IntVar(&o.Books, "generate", 0, "also load a generated catalogue of this many books")
+	fs.IntVar(&o.Authors, "authors", 0, "authors of the generated catalogue, one per ten books by default")
+	fs.Int64Var(&o.Seed, "seed", 1, "random seed of the generated catalogue")
+	cfg, paths, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 && o.Books <= 0 {
+		return fmt.Errorf("usage: seed [flags] [file.yaml|file.json...], with a file or -generate")
+	}
+
+	var sets []*fixture.Set
+	for _, path := range paths {
+		set, err := fixture.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, set)
+	}
+	if o.Books > 0 {
+		sets = append(sets, fixture.Generate(o))
+	}
+
+	conn, err := db.Open(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := fixture.Load(store.NewStoreFor(conn, cfg.DB.Driver).WithActor("seed"), sets...)
+	if err != nil {
+		return err
+	}
+	log.Printf("seed: loaded %d genres, %d authors and %d books\n", len(res.Genres), len(res.Authors), len(res.Books))
+	return nil
+}
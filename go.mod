@@ -6,6 +6,9 @@ require (
 	github.com/golang-migrate/migrate v3.5.4+incompatible
 	github.com/google/go-github v17.0.0+incompatible // indirect
 	github.com/google/go-querystring v1.0.0 // indirect
-	github.com/mattn/go-sqlite3 v1.14.4
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.10
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
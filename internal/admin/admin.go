@@ -0,0 +1,321 @@
+// Package admin implements the catalogue commands of the command-line tool:
+// listing, showing, adding, updating, deleting and searching books, authors
+// and genres directly through the store.
+package admin
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DateLayout is the layout of dates in flags and table output.
+const DateLayout = "2006-01-02"
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Actions every resource supports.
+var Actions = []string{"list", "get", "add", "update", "delete", "search"}
+
+// ErrAborted is returned when a destructive command is not confirmed.
+var ErrAborted = errors.New("aborted")
+
+// Command is one action on one resource, such as "books add". New registers
+// its flags on a flag set; Run executes it once the flags are parsed.
+type Command struct {
+	// Names is the format author names are shown in.
+	Names models.NameFormat
+	// In is read for confirmations, Out receives the result and Err prompts.
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+
+	resource string
+	action   string
+	run      func(s *store.Store, args []string) error
+
+	format  string
+	yes     bool
+	actor   string
+	page    int
+	perPage int
+
+	// edits are the changes given by flags to the record being added or
+	// updated, applied in flag order once the record is loaded.
+	edits []func()
+}
+
+// New returns the command for action on resource ("books", "authors" or
+// "genres") and registers its flags on fs.
+func New(resource, action string, fs *flag.FlagSet) (*Command, error) {
+	c := &Command{
+		Names:    models.NameLastFirst,
+		In:       os.Stdin,
+		Out:      os.Stdout,
+		Err:      os.Stderr,
+		resource: resource,
+		action:   action,
+	}
+
+	var register func(c *Command, fs *flag.FlagSet) bool
+	switch resource {
+	case "books":
+		register = registerBooks
+	case "authors":
+		register = registerAuthors
+	case "genres":
+		register = registerGenres
+	default:
+		return nil, fmt.Errorf("unknown resource %q, want books, authors or genres", resource)
+	}
+
+	fs.StringVar(&c.format, "format", FormatTable, "output format: table or json")
+	switch action {
+	case "list":
+		fs.IntVar(&c.page, "page", 1, "page to list")
+		fs.IntVar(&c.perPage, "per-page", 20, "records per page")
+	case "add", "update", "delete":
+		fs.StringVar(&c.actor, "actor", "cli", "name recorded in the audit log")
+	}
+	if action == "delete" {
+		fs.BoolVar(&c.yes, "yes", false, "do not ask for confirmation")
+	}
+
+	if !register(c, fs) {
+		return nil, fmt.Errorf("unknown action %q, want one of %s", action, strings.Join(Actions, ", "))
+	}
+	return c, nil
+}
+
+// Run executes the command against s with the positional arguments left
+// after the flags.
+func (c *Command) Run(s *store.Store, args []string) error {
+	if c.format != FormatTable && c.format != FormatJSON {
+		return fmt.Errorf("unknown format %q, want %s or %s", c.format, FormatTable, FormatJSON)
+	}
+	if c.page < 0 || c.perPage < 0 {
+		return fmt.Errorf("page and per-page must not be negative")
+	}
+	if c.actor != "" {
+		s = s.WithActor(c.actor)
+	}
+	return c.run(s, args)
+}
+
+// usage returns the error for a command called with the wrong arguments.
+func (c *Command) usage(args string) error {
+	return fmt.Errorf("usage: %s %s [flags] %s", c.resource, c.action, args)
+}
+
+// oneArg returns the single positional argument named name.
+func (c *Command) oneArg(args []string, name string) (string, error) {
+	if len(args) != 1 {
+		return "", c.usage("<" + name + ">")
+	}
+	return args[0], nil
+}
+
+// idArg returns the single positional argument as a record id.
+func (c *Command) idArg(args []string) (int, error) {
+	arg, err := c.oneArg(args, "id")
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%q is not a valid id", arg)
+	}
+	return id, nil
+}
+
+// noArgs rejects positional arguments.
+func (c *Command) noArgs(args []string) error {
+	if len(args) != 0 {
+		return c.usage("")
+	}
+	return nil
+}
+
+// notFound turns sql.ErrNoRows into a message naming the record.
+func notFound(err error, what string, key interface{}) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s %v not found", what, key)
+	}
+	return err
+}
+
+// checkValid reports the first problem of a record the way the models report
+// it, before anything is written.
+func checkValid(ok bool, problem string) error {
+	if !ok {
+		return errors.New(problem)
+	}
+	return nil
+}
+
+// confirm asks whether to go ahead, unless -yes was given. Anything but y or
+// yes, including end of input, aborts.
+func (c *Command) confirm(format string, args ...interface{}) error {
+	if c.yes {
+		return nil
+	}
+	fmt.Fprintf(c.Err, format+" [y/N] ", args...)
+	answer, _ := bufio.NewReader(c.In).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return ErrAborted
+}
+
+// print writes v as indented JSON, or the rows under header as a table.
+func (c *Command) print(v interface{}, header []string, rows [][]string) error {
+	if c.format == FormatJSON {
+		enc := json.NewEncoder(c.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printDone reports a change made by a command that prints no record.
+func (c *Command) printDone(what string, id int64, done string) error {
+	if c.format == FormatJSON {
+		return c.print(map[string]interface{}{"id": id, "result": done}, nil, nil)
+	}
+	_, err := fmt.Fprintf(c.Out, "%s %d %s\n", what, id, done)
+	return err
+}
+
+// edit registers a flag that changes the record being added or updated.
+// parse checks the value when the flags are parsed and returns the change.
+func (c *Command) edit(fs *flag.FlagSet, name, usage string, parse func(value string) (func(), error)) {
+	fs.Var(setter(func(value string) error {
+		change, err := parse(value)
+		if err != nil {
+			return err
+		}
+		c.edits = append(c.edits, change)
+		return nil
+	}), name, usage)
+}
+
+func (c *Command) stringField(fs *flag.FlagSet, name, usage string, field *string) {
+	c.edit(fs, name, usage, func(value string) (func(), error) {
+		return func() { *field = value }, nil
+	})
+}
+
+func (c *Command) listField(fs *flag.FlagSet, name, usage string, field *[]string) {
+	c.edit(fs, name, usage+", comma separated", func(value string) (func(), error) {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return func() { *field = list }, nil
+	})
+}
+
+func (c *Command) uintField(fs *flag.FlagSet, name, usage string, field *uint) {
+	c.edit(fs, name, usage, func(value string) (func(), error) {
+		n, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a whole number", value)
+		}
+		return func() { *field = uint(n) }, nil
+	})
+}
+
+func (c *Command) idField(fs *flag.FlagSet, name, usage string, field *int64) {
+	c.edit(fs, name, usage, func(value string) (func(), error) {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("%q is not a valid id", value)
+		}
+		return func() { *field = id }, nil
+	})
+}
+
+func (c *Command) dateField(fs *flag.FlagSet, name, usage string, field *time.Time) {
+	c.edit(fs, name, usage+" ("+DateLayout+")", func(value string) (func(), error) {
+		date, err := parseDate(value)
+		if err != nil {
+			return nil, err
+		}
+		return func() { *field = date }, nil
+	})
+}
+
+// optionalDateField sets field to nil for an empty value.
+func (c *Command) optionalDateField(fs *flag.FlagSet, name, usage string, field **time.Time) {
+	c.edit(fs, name, usage+" ("+DateLayout+", empty for none)", func(value string) (func(), error) {
+		if value == "" {
+			return func() { *field = nil }, nil
+		}
+		date, err := parseDate(value)
+		if err != nil {
+			return nil, err
+		}
+		return func() { *field = &date }, nil
+	})
+}
+
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date such as 2006-01-02", value)
+	}
+	return date, nil
+}
+
+// applyEdits applies the changes given by flags. An update without any
+// fails, as it would change nothing.
+func (c *Command) applyEdits() error {
+	if len(c.edits) == 0 && c.action == "update" {
+		return fmt.Errorf("nothing to change, see %s %s -h", c.resource, c.action)
+	}
+	for _, change := range c.edits {
+		change()
+	}
+	return nil
+}
+
+// setter is a flag.Value calling a function with each value given.
+type setter func(value string) error
+
+func (f setter) Set(value string) error { return f(value) }
+func (f setter) String() string         { return "" }
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(DateLayout)
+}
+
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,241 @@
+package admin
+
+import (
+	"bookland/internal/fixture"
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// run executes "resource action args" against s, answering confirmations
+// with input, and returns what the command printed.
+func run(s *store.Store, resource, action, input string, args ...string) (string, error) {
+	fs := flag.NewFlagSet(resource+" "+action, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	c, err := New(resource, action, fs)
+	if err != nil {
+		return "", err
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	c.In = strings.NewReader(input)
+	c.Out = &out
+	c.Err = ioutil.Discard
+	err = c.Run(s, fs.Args())
+	return out.String(), err
+}
+
+func TestBooks_List(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	out, err := run(s, "books", "list", "")
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Equal(t, 17, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.Contains(t, lines[1], "test book 16")
+
+	out, err = run(s, "books", "list", "", "-format", "json", "-per-page", "5", "-page", "2", "-genre", "2")
+	assert.NoError(t, err)
+	var books []models.Book
+	assert.NoError(t, json.Unmarshal([]byte(out), &books))
+	if assert.Equal(t, 1, len(books)) {
+		assert.Equal(t, int64(11), books[0].Id)
+	}
+
+	_, err = run(s, "books", "list", "", "-author", "1", "-genre", "1")
+	assert.Error(t, err)
+	_, err = run(s, "books", "list", "", "-format", "xml")
+	assert.Error(t, err)
+}
+
+func TestBooks_GetAndSearch(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	out, err := run(s, "books", "get", "", "-format", "json", "3")
+	assert.NoError(t, err)
+	var book models.Book
+	assert.NoError(t, json.Unmarshal([]byte(out), &book))
+	assert.Equal(t, "test book 3", book.Name)
+
+	_, err = run(s, "books", "get", "", "99")
+	assert.EqualError(t, err, "book 99 not found")
+	_, err = run(s, "books", "get", "")
+	assert.Error(t, err)
+
+	out, err = run(s, "books", "search", "", "book 1")
+	assert.NoError(t, err)
+	assert.Equal(t, 9, len(strings.Split(strings.TrimSpace(out), "\n")))
+}
+
+func TestBooks_AddAndUpdate(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	out, err := run(s, "books", "add", "", "-format", "json", "-name", "Dune", "-isbn", "9780441013593",
+		"-release", "1965-08-01", "-coast", "999", "-pages", "412", "-author", "2", "-genre", "1")
+	assert.NoError(t, err)
+	var added models.Book
+	assert.NoError(t, json.Unmarshal([]byte(out), &added))
+	assert.Equal(t, "Dune", added.Name)
+	assert.Equal(t, "Laurence Freddy", added.AuthorName)
+
+	// ISBNs without hyphens are found although they read as ids.
+	for _, isbn := range []string{"9780441013593", "0441013597", "978-0-441-01359-3"} {
+		out, err = run(s, "books", "get", "", "-format", "json", isbn)
+		assert.NoError(t, err)
+		var found models.Book
+		assert.NoError(t, json.Unmarshal([]byte(out), &found))
+		assert.Equal(t, added.Id, found.Id)
+	}
+
+	_, err = run(s, "books", "add", "", "-name", "Dune", "-isbn", "123",
+		"-release", "1965-08-01", "-coast", "999", "-pages", "412", "-author", "2", "-genre", "1")
+	assert.EqualError(t, err, "ISBN is invalid")
+	_, err = run(s, "books", "add", "", "-release", "01.08.1965")
+	assert.Error(t, err)
+
+	_, err = run(s, "books", "update", "", "-name", "Dune Messiah", "-pages", "256", formatInt(added.Id))
+	assert.NoError(t, err)
+	updated, err := s.Books.GetById(int(added.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", updated.Name)
+	assert.Equal(t, uint(256), updated.Pages)
+	assert.Equal(t, uint(999), updated.Coast)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = run(s, "books", "update", "", "-name", "", formatInt(added.Id))
+	assert.EqualError(t, err, "Book name is require field")
+	_, err = run(s, "books", "update", "", formatInt(added.Id))
+	assert.Error(t, err)
+}
+
+func TestBooks_Delete(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	for _, answer := range []string{"", "n\n", "no\n"} {
+		_, err := run(s, "books", "delete", answer, "1")
+		assert.Equal(t, ErrAborted, err)
+	}
+	_, err := s.Books.GetById(1)
+	assert.NoError(t, err)
+
+	out, err := run(s, "books", "delete", "y\n", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "book 1 deleted\n", out)
+	_, err = s.Books.GetById(1)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, err = run(s, "books", "delete", "", "-yes", "-actor", "ops", "2")
+	assert.NoError(t, err)
+	entries, err := s.Audit.History("book", 2, 10, 1)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, entries) {
+		assert.Equal(t, models.AuditDelete, entries[0].Action)
+		assert.Equal(t, "ops", entries[0].Actor)
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	out, err := run(s, "authors", "search", "", "potter")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Potter Harry")
+
+	out, err = run(s, "authors", "add", "", "-format", "json", "-last-name", "Herbert", "-first-name", "Frank",
+		"-birthday", "1920-10-08", "-death-day", "1986-02-11", "-social-links", "https://example.com/a, https://example.com/b")
+	assert.NoError(t, err)
+	var author models.Author
+	assert.NoError(t, json.Unmarshal([]byte(out), &author))
+	assert.Equal(t, "frank-herbert", author.Slug)
+	assert.Equal(t, []string{"https://example.com/a", "https://example.com/b"}, author.SocialLinks)
+
+	_, err = run(s, "authors", "update", "", "-death-day", "", "-nationality", "US", "frank-herbert")
+	assert.Error(t, err)
+	_, err = run(s, "authors", "update", "", "-death-day", "", "-nationality", "US", formatInt(author.Id))
+	assert.NoError(t, err)
+
+	out, err = run(s, "authors", "get", "", "frank-herbert")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "US")
+	updated, err := s.Authors.Get(int(author.Id))
+	assert.NoError(t, err)
+	assert.Nil(t, updated.DeathDay)
+
+	_, err = run(s, "authors", "add", "", "-last-name", "Nobody")
+	assert.EqualError(t, err, "First name is require field")
+
+	_, err = run(s, "authors", "delete", "yes\n", "1")
+	assert.NoError(t, err)
+	books, err := s.Books.GetByAuthor(1, 20, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, books)
+}
+
+func TestGenres(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	out, err := run(s, "genres", "add", "", "-name", " Poetry ")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Poetry")
+
+	out, err = run(s, "genres", "list", "", "-format", "json")
+	assert.NoError(t, err)
+	var genres []models.Genre
+	assert.NoError(t, json.Unmarshal([]byte(out), &genres))
+	assert.Equal(t, 3, len(genres))
+
+	out, err = run(s, "genres", "search", "", "poe")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(strings.Split(strings.TrimSpace(out), "\n")))
+
+	_, err = run(s, "genres", "add", "", "-name", "1984")
+	assert.NoError(t, err)
+	out, err = run(s, "genres", "get", "", "1984")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "1984")
+	_, err = run(s, "genres", "delete", "", "-yes", "4")
+	assert.NoError(t, err)
+
+	_, err = run(s, "genres", "update", "", "-name", "Verse", "3")
+	assert.NoError(t, err)
+	genre, err := s.Genres.GetByName("Verse")
+	assert.NoError(t, err)
+
+	_, err = run(s, "genres", "delete", "", "-yes", "1")
+	assert.Equal(t, store.ErrGenreInUse, err)
+	_, err = run(s, "genres", "delete", "y\n", formatInt(genre.Id))
+	assert.NoError(t, err)
+	_, err = run(s, "genres", "get", "", "Verse")
+	assert.EqualError(t, err, "genre Verse not found")
+}
+
+func TestNew_Unknown(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	_, err := New("publishers", "list", fs)
+	assert.Error(t, err)
+	_, err = New("books", "purge", fs)
+	assert.Error(t, err)
+}
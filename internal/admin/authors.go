@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"database/sql"
+	"errors"
+	"flag"
+	"strconv"
+)
+
+// registerAuthors sets up the authors commands, reporting false for an
+// unknown action.
+func registerAuthors(c *Command, fs *flag.FlagSet) bool {
+	author := &models.Author{}
+	switch c.action {
+	case "list":
+		c.run = c.listAuthors
+	case "get":
+		c.run = c.getAuthor
+	case "search":
+		c.run = c.searchAuthors
+	case "add", "update":
+		c.stringField(fs, "last-name", "last name", &author.LastName)
+		c.stringField(fs, "first-name", "first name", &author.FirstName)
+		c.stringField(fs, "middle-name", "middle name", &author.MiddleName)
+		c.stringField(fs, "pen-name", "name the author publishes under", &author.PenName)
+		c.dateField(fs, "birthday", "birth date", &author.BirthDay)
+		c.optionalDateField(fs, "death-day", "death date", &author.DeathDay)
+		c.stringField(fs, "bio", "biography", &author.Bio)
+		c.stringField(fs, "nationality", "ISO 3166-1 alpha-2 country code", &author.Nationality)
+		c.stringField(fs, "portrait", "portrait URL", &author.PortraitURL)
+		c.stringField(fs, "website", "website URL", &author.Website)
+		c.listField(fs, "social-links", "social profile URLs", &author.SocialLinks)
+		c.stringField(fs, "slug", "URL slug, derived from the name when empty", &author.Slug)
+		if c.action == "add" {
+			c.run = func(s *store.Store, args []string) error { return c.addAuthor(s, args, author) }
+		} else {
+			c.run = func(s *store.Store, args []string) error { return c.updateAuthor(s, args, author) }
+		}
+	case "delete":
+		c.run = c.deleteAuthor
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *Command) listAuthors(s *store.Store, args []string) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+
+	authors, err := s.Authors.GetPerPage(c.perPage, c.page)
+	if err != nil {
+		return err
+	}
+	return c.printAuthors(authors)
+}
+
+// getAuthor shows an author by id or slug. A number that is no author's id
+// is looked up as a slug.
+func (c *Command) getAuthor(s *store.Store, args []string) error {
+	arg, err := c.oneArg(args, "id or slug")
+	if err != nil {
+		return err
+	}
+
+	var author *models.Author
+	id, convErr := strconv.Atoi(arg)
+	if convErr == nil {
+		author, err = s.Authors.Get(id)
+	}
+	if convErr != nil || errors.Is(err, sql.ErrNoRows) {
+		author, err = s.Authors.GetBySlug(arg)
+	}
+	if err != nil {
+		return notFound(err, "author", arg)
+	}
+	return c.printAuthor(author)
+}
+
+func (c *Command) searchAuthors(s *store.Store, args []string) error {
+	value, err := c.oneArg(args, "text")
+	if err != nil {
+		return err
+	}
+
+	authors, err := s.Authors.SearchByName(value)
+	if err != nil {
+		return err
+	}
+	return c.printAuthors(authors)
+}
+
+func (c *Command) addAuthor(s *store.Store, args []string, author *models.Author) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	author.Normalize()
+	if err := checkValid(author.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Authors.Add(author); err != nil {
+		return err
+	}
+	return c.printAuthor(author)
+}
+
+// updateAuthor changes the fields given by flags and keeps the others.
+func (c *Command) updateAuthor(s *store.Store, args []string, author *models.Author) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.Authors.Get(id)
+	if err != nil {
+		return notFound(err, "author", id)
+	}
+	*author = *current
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	author.Normalize()
+	if err := checkValid(author.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Authors.Update(author); err != nil {
+		return err
+	}
+	return c.printAuthor(author)
+}
+
+// deleteAuthor moves an author and all of their books to the trash after
+// confirmation.
+func (c *Command) deleteAuthor(s *store.Store, args []string) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	author, err := s.Authors.Get(id)
+	if err != nil {
+		return notFound(err, "author", id)
+	}
+	if err := c.confirm("Move author %d %q and all of their books to the trash?", author.Id, author.DisplayName(c.Names)); err != nil {
+		return err
+	}
+
+	if err := s.Authors.Delete(id); err != nil {
+		return err
+	}
+	return c.printDone("author", author.Id, "deleted")
+}
+
+var authorHeader = []string{"ID", "NAME", "SLUG", "BORN", "NATIONALITY", "VERSION"}
+
+func (c *Command) authorRow(a *models.Author) []string {
+	return []string{formatInt(a.Id), a.DisplayName(c.Names), a.Slug, formatDate(a.BirthDay), a.Nationality, formatInt(a.Version)}
+}
+
+func (c *Command) printAuthors(authors []models.Author) error {
+	if authors == nil {
+		authors = []models.Author{}
+	}
+	rows := make([][]string, len(authors))
+	for i := range authors {
+		rows[i] = c.authorRow(&authors[i])
+	}
+	return c.print(authors, authorHeader, rows)
+}
+
+func (c *Command) printAuthor(author *models.Author) error {
+	return c.print(author, authorHeader, [][]string{c.authorRow(author)})
+}
@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// registerBooks sets up the books commands, reporting false for an unknown
+// action.
+func registerBooks(c *Command, fs *flag.FlagSet) bool {
+	book := &models.Book{}
+	switch c.action {
+	case "list":
+		var author, genre int
+		fs.IntVar(&author, "author", 0, "only list books by this author id")
+		fs.IntVar(&genre, "genre", 0, "only list books of this genre id")
+		c.run = func(s *store.Store, args []string) error {
+			return c.listBooks(s, args, author, genre)
+		}
+	case "get":
+		c.run = c.getBook
+	case "search":
+		c.run = c.searchBooks
+	case "add", "update":
+		c.stringField(fs, "name", "book name", &book.Name)
+		c.stringField(fs, "isbn", "ISBN-10 or ISBN-13", &book.ISBN)
+		c.dateField(fs, "release", "release date", &book.Release)
+		c.uintField(fs, "coast", "price in cents", &book.Coast)
+		c.uintField(fs, "pages", "number of pages", &book.Pages)
+		c.stringField(fs, "poster", "poster URL", &book.PosterURL)
+		c.idField(fs, "author", "author id", &book.AuthorId)
+		c.idField(fs, "genre", "genre id", &book.GenreId)
+		if c.action == "add" {
+			c.run = func(s *store.Store, args []string) error { return c.addBook(s, args, book) }
+		} else {
+			c.run = func(s *store.Store, args []string) error { return c.updateBook(s, args, book) }
+		}
+	case "delete":
+		c.run = c.deleteBook
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *Command) listBooks(s *store.Store, args []string, author, genre int) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+
+	var books []models.Book
+	var err error
+	switch {
+	case author > 0 && genre > 0:
+		return fmt.Errorf("filter by -author or -genre, not both")
+	case author > 0:
+		books, err = s.Books.GetByAuthor(author, c.perPage, c.page)
+	case genre > 0:
+		books, err = s.Books.GetByGenre(genre, c.perPage, c.page)
+	default:
+		books, err = s.Books.GetPerPage(c.perPage, c.page)
+	}
+	if err != nil {
+		return err
+	}
+	return c.printBooks(books)
+}
+
+// getBook shows a book by id or ISBN. ISBNs without hyphens are numbers too,
+// so a number that is no book's id is looked up as an ISBN.
+func (c *Command) getBook(s *store.Store, args []string) error {
+	arg, err := c.oneArg(args, "id or ISBN")
+	if err != nil {
+		return err
+	}
+
+	var book *models.Book
+	id, convErr := strconv.Atoi(arg)
+	if convErr == nil {
+		book, err = s.Books.GetById(id)
+	}
+	if convErr != nil || (errors.Is(err, sql.ErrNoRows) && models.IsValidISBN(arg)) {
+		book, err = s.Books.GetByISBN(arg)
+	}
+	if err != nil {
+		return notFound(err, "book", arg)
+	}
+	return c.printBook(book)
+}
+
+func (c *Command) searchBooks(s *store.Store, args []string) error {
+	value, err := c.oneArg(args, "text")
+	if err != nil {
+		return err
+	}
+
+	books, err := s.Books.Search(value)
+	if err != nil {
+		return err
+	}
+	return c.printBooks(books)
+}
+
+func (c *Command) addBook(s *store.Store, args []string, book *models.Book) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	if err := checkValid(book.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Books.Add(book); err != nil {
+		return err
+	}
+	return c.getBook(s, []string{formatInt(book.Id)})
+}
+
+// updateBook changes the fields given by flags and keeps the others.
+func (c *Command) updateBook(s *store.Store, args []string, book *models.Book) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.Books.GetById(id)
+	if err != nil {
+		return notFound(err, "book", id)
+	}
+	*book = *current
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	if err := checkValid(book.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Books.Update(book); err != nil {
+		return err
+	}
+	return c.getBook(s, []string{formatInt(book.Id)})
+}
+
+// deleteBook moves a book to the trash after confirmation.
+func (c *Command) deleteBook(s *store.Store, args []string) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	book, err := s.Books.GetById(id)
+	if err != nil {
+		return notFound(err, "book", id)
+	}
+	if err := c.confirm("Move book %d %q by %s to the trash?", book.Id, book.Name, book.AuthorName); err != nil {
+		return err
+	}
+
+	if err := s.Books.Delete(id, int(book.AuthorId)); err != nil {
+		return err
+	}
+	return c.printDone("book", book.Id, "deleted")
+}
+
+var bookHeader = []string{"ID", "NAME", "AUTHOR", "GENRE", "ISBN", "RELEASE", "PAGES", "COAST", "VERSION"}
+
+func bookRow(b *models.Book) []string {
+	return []string{
+		formatInt(b.Id), b.Name, b.AuthorName, b.GenreName, b.ISBN, formatDate(b.Release),
+		fmt.Sprint(b.Pages), fmt.Sprint(b.Coast), formatInt(b.Version),
+	}
+}
+
+func (c *Command) printBooks(books []models.Book) error {
+	if books == nil {
+		books = []models.Book{}
+	}
+	rows := make([][]string, len(books))
+	for i := range books {
+		rows[i] = bookRow(&books[i])
+	}
+	return c.print(books, bookHeader, rows)
+}
+
+func (c *Command) printBook(book *models.Book) error {
+	return c.print(book, bookHeader, [][]string{bookRow(book)})
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"database/sql"
+	"errors"
+	"flag"
+	"strconv"
+)
+
+// registerGenres sets up the genres commands, reporting false for an unknown
+// action. Genres are few, so list shows all of them.
+func registerGenres(c *Command, fs *flag.FlagSet) bool {
+	genre := &models.Genre{}
+	switch c.action {
+	case "list":
+		c.run = c.listGenres
+	case "get":
+		c.run = c.getGenre
+	case "search":
+		c.run = c.searchGenres
+	case "add", "update":
+		c.stringField(fs, "name", "genre name", &genre.Name)
+		if c.action == "add" {
+			c.run = func(s *store.Store, args []string) error { return c.addGenre(s, args, genre) }
+		} else {
+			c.run = func(s *store.Store, args []string) error { return c.updateGenre(s, args, genre) }
+		}
+	case "delete":
+		c.run = c.deleteGenre
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *Command) listGenres(s *store.Store, args []string) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+
+	genres, err := s.Genres.GetAll()
+	if err != nil {
+		return err
+	}
+	return c.printGenres(genres)
+}
+
+// getGenre shows a genre by id or name. A number that is no genre's id is
+// looked up as a name.
+func (c *Command) getGenre(s *store.Store, args []string) error {
+	arg, err := c.oneArg(args, "id or name")
+	if err != nil {
+		return err
+	}
+
+	var genre *models.Genre
+	id, convErr := strconv.Atoi(arg)
+	if convErr == nil {
+		genre, err = s.Genres.Get(id)
+	}
+	if convErr != nil || errors.Is(err, sql.ErrNoRows) {
+		genre, err = s.Genres.GetByName(arg)
+	}
+	if err != nil {
+		return notFound(err, "genre", arg)
+	}
+	return c.printGenre(genre)
+}
+
+func (c *Command) searchGenres(s *store.Store, args []string) error {
+	value, err := c.oneArg(args, "text")
+	if err != nil {
+		return err
+	}
+
+	genres, err := s.Genres.Search(value)
+	if err != nil {
+		return err
+	}
+	return c.printGenres(genres)
+}
+
+func (c *Command) addGenre(s *store.Store, args []string, genre *models.Genre) error {
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	genre.Normalize()
+	if err := checkValid(genre.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Genres.Add(genre); err != nil {
+		return err
+	}
+	return c.printGenre(genre)
+}
+
+func (c *Command) updateGenre(s *store.Store, args []string, genre *models.Genre) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.Genres.Get(id)
+	if err != nil {
+		return notFound(err, "genre", id)
+	}
+	*genre = *current
+	if err := c.applyEdits(); err != nil {
+		return err
+	}
+	genre.Normalize()
+	if err := checkValid(genre.IsValid()); err != nil {
+		return err
+	}
+
+	if err := s.Genres.Update(genre); err != nil {
+		return err
+	}
+	return c.printGenre(genre)
+}
+
+// deleteGenre removes a genre no book refers to after confirmation.
+func (c *Command) deleteGenre(s *store.Store, args []string) error {
+	id, err := c.idArg(args)
+	if err != nil {
+		return err
+	}
+
+	genre, err := s.Genres.Get(id)
+	if err != nil {
+		return notFound(err, "genre", id)
+	}
+	if err := c.confirm("Delete genre %d %q for good?", genre.Id, genre.Name); err != nil {
+		return err
+	}
+
+	if err := s.Genres.Delete(id); err != nil {
+		return err
+	}
+	return c.printDone("genre", genre.Id, "deleted")
+}
+
+var genreHeader = []string{"ID", "NAME", "VERSION"}
+
+func (c *Command) printGenres(genres []models.Genre) error {
+	if genres == nil {
+		genres = []models.Genre{}
+	}
+	rows := make([][]string, len(genres))
+	for i, g := range genres {
+		rows[i] = []string{formatInt(g.Id), g.Name, formatInt(g.Version)}
+	}
+	return c.print(genres, genreHeader, rows)
+}
+
+func (c *Command) printGenre(genre *models.Genre) error {
+	return c.print(genre, genreHeader, [][]string{{formatInt(genre.Id), genre.Name, formatInt(genre.Version)}})
+}
@@ -0,0 +1,216 @@
+// Package backup takes consistent snapshots of a live SQLite database with
+// VACUUM INTO, keeps the newest of them and restores the database from one.
+package backup
+
+import (
+	"bookland/internal/db"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".db"
+	// timeLayout sorts lexically in time order.
+	timeLayout = "20060102T150405.000Z"
+)
+
+type Snapshot struct {
+	Name      string
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Create writes a snapshot of the database into dir and verifies it. VACUUM
+// INTO reads the database in one transaction, so the snapshot is consistent
+// while the server keeps writing.
+func Create(conn *sql.DB, dir string) (Snapshot, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Snapshot{}, err
+	}
+
+	now := time.Now().UTC()
+	name := snapshotPrefix + now.Format(timeLayout) + snapshotSuffix
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+
+	if _, err := conn.Exec("VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := Verify(tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Name: name, Path: path, CreatedAt: now, Size: info.Size()}, nil
+}
+
+// List returns the snapshots in dir, newest first.
+func List(dir string) ([]Snapshot, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+		created, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:      name,
+			Path:      filepath.Join(dir, name),
+			CreatedAt: created,
+			Size:      f.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name > snapshots[j].Name })
+	return snapshots, nil
+}
+
+// Rotate deletes all but the keep newest snapshots in dir and returns the
+// deleted ones.
+func Rotate(dir string, keep int) ([]Snapshot, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) <= keep {
+		return nil, nil
+	}
+
+	removed := snapshots[keep:]
+	for _, s := range removed {
+		if err := os.Remove(s.Path); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// Find returns the snapshot in dir with the given name.
+func Find(dir, name string) (Snapshot, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	for _, s := range snapshots {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("no snapshot %s in %s", name, dir)
+}
+
+// Verify runs PRAGMA integrity_check on the database file at path.
+func Verify(path string) error {
+	conn, err := db.NewSQLiteDB("file:" + path + "?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("check %s: %w", path, err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("check %s: %w", path, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s failed the integrity check: %s", path, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Restore replaces the database file at target with the snapshot after
+// verifying it. The replaced file and its WAL are kept next to it with the
+// suffix ".before-restore". Nothing may have target open meanwhile.
+func Restore(snapshot Snapshot, target string) error {
+	if err := Verify(snapshot.Path); err != nil {
+		return err
+	}
+
+	tmp := target + ".restore-tmp"
+	if err := copyFile(snapshot.Path, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Clear out an earlier restore first, so its WAL cannot end up next to
+	// the file replaced now.
+	aside := target + ".before-restore"
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(aside + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(target+suffix, aside+suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, target)
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
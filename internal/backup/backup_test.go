@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateAndRotate(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	var created []Snapshot
+	for i := 0; i < 3; i++ {
+		s, err := Create(conn, dir)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.FileExists(t, s.Path)
+		assert.NotZero(t, s.Size)
+		created = append(created, s)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// Files that are not snapshots are left alone.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644))
+
+	snapshots, err := List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+	assert.Equal(t, created[2].Name, snapshots[0].Name)
+
+	removed, err := Rotate(dir, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, created[0].Name, removed[0].Name)
+	}
+
+	snapshots, err = List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+
+	_, err = Find(dir, created[0].Name)
+	assert.Error(t, err)
+	found, err := Find(dir, created[1].Name)
+	assert.NoError(t, err)
+	assert.Equal(t, created[1].Path, found.Path)
+}
+
+func TestVerify(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+
+	s, err := Create(conn, t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, Verify(s.Path))
+
+	// Overwrite a page in the middle of the file.
+	data, err := ioutil.ReadFile(s.Path)
+	assert.NoError(t, err)
+	for i := 4096 * 2; i < 4096*3 && i < len(data); i++ {
+		data[i] = 0xff
+	}
+	assert.NoError(t, ioutil.WriteFile(s.Path, data, 0644))
+	assert.Error(t, Verify(s.Path))
+
+	assert.Error(t, Verify(filepath.Join(t.TempDir(), "missing.db")))
+}
+
+func TestRestore(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+
+	s, err := Create(conn, t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	target := filepath.Join(t.TempDir(), "book.db")
+	live, err := db.NewSQLiteDB(target)
+	assert.NoError(t, err)
+	_, err = live.Exec("CREATE TABLE other(id INTEGER)")
+	assert.NoError(t, err)
+	assert.NoError(t, live.Close())
+
+	assert.NoError(t, Restore(s, target))
+	assert.FileExists(t, target+".before-restore")
+
+	restored, err := db.NewSQLiteDB(target)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer restored.Close()
+	var books int
+	assert.NoError(t, restored.QueryRow("SELECT COUNT(id) FROM book").Scan(&books))
+	assert.Equal(t, 16, books)
+
+	// A corrupt snapshot leaves the database untouched.
+	assert.NoError(t, ioutil.WriteFile(s.Path, []byte("not a database"), 0644))
+	assert.Error(t, Restore(s, target))
+	_, err = os.Stat(target)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/media/")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Put("posters/1/a.png", strings.NewReader("first")))
+	assert.NoError(t, s.Put("posters/1/a.png", strings.NewReader("second")))
+	assert.NoError(t, s.Put("posters/2/b.png", strings.NewReader("other")))
+	assert.NoError(t, s.Put("notes.txt", strings.NewReader("note")))
+
+	r, err := s.Open("posters/1/a.png")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "second", string(data))
+
+	keys, err := s.List("posters/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"posters/1/a.png", "posters/2/b.png"}, keys)
+
+	keys, err = s.List("posters/2/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"posters/2/b.png"}, keys)
+
+	keys, err = s.List("posters/3/")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	assert.Equal(t, "/media/posters/1/a.png", s.URL("posters/1/a.png"))
+
+	assert.NoError(t, s.Delete("posters/1/a.png"))
+	assert.NoError(t, s.Delete("posters/1/a.png"))
+	_, err = s.Open("posters/1/a.png")
+	assert.Equal(t, ErrNotFound, err)
+
+	keys, err = s.List("posters/1/")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/media")
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", "a/.tmp-1"} {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, ErrInvalidKey, s.Put(key, strings.NewReader("x")))
+			_, err := s.Open(key)
+			assert.Equal(t, ErrInvalidKey, err)
+			assert.Equal(t, ErrInvalidKey, s.Delete(key))
+		})
+	}
+}
+
+func TestLocalStore_Handler(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	assert.NoError(t, s.Put("posters/1/a.txt", strings.NewReader("poster")))
+	h := http.StripPrefix("/media/", s.Handler())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media/posters/1/a.txt", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "poster", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media/posters/1/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
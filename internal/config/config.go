@@ -0,0 +1,278 @@
+// Package config gathers the server settings from a JSON config file,
+// environment variables and command-line flags, in increasing precedence.
+package config
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/models"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+// ConfigEnv names the environment variable with the config file path, used
+// when the -config flag is not given.
+const ConfigEnv = "BOOKLAND_CONFIG"
+
+type Config struct {
+	Addr string
+	// TrustActorHeader records the actor a request names in its X-Actor
+	// header in the audit log. Only enable it behind an authenticating proxy
+	// that sets the header.
+	TrustActorHeader bool
+	NameFormat       models.NameFormat
+	MediaDir         string
+	MediaURL         string
+	DB               db.Config
+	Backup           Backup
+	Trash            Trash
+}
+
+// Backup configures the snapshots of a SQLite database. An Interval of 0
+// leaves backups to the backup command.
+type Backup struct {
+	Dir      string
+	Keep     int
+	Interval time.Duration
+}
+
+// Trash configures how long books and authors stay in the trash before
+// they and their poster files are purged. A PurgeInterval of 0 leaves
+// purging to the purge command.
+type Trash struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+func Default() Config {
+	return Config{
+		Addr:       ":8080",
+		NameFormat: models.NameLastFirst,
+		MediaDir:   "media",
+		MediaURL:   "/media/",
+		DB:         db.DefaultConfig(),
+		Backup:     Backup{Dir: "backups", Keep: 7},
+		Trash:      Trash{Retention: 30 * 24 * time.Hour},
+	}
+}
+
+// setting is one configurable value with its flag, environment variable and
+// config file key.
+type setting struct {
+	flag  string
+	env   string
+	key   string
+	usage string
+	value flag.Value
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{"addr", "BOOKLAND_ADDR", "addr", "HTTP listen address", (*stringValue)(&c.Addr)},
+		{"trust-actor-header", "BOOKLAND_TRUST_ACTOR_HEADER", "trust_actor_header",
+			"audit changes as the X-Actor header says; only behind a proxy that authenticates users and sets it", (*boolValue)(&c.TrustActorHeader)},
+		{"name-format", "BOOKLAND_NAME_FORMAT", "name_format",
+			"author name format: last_first, first_last or last_initials", (*nameFormatValue)(&c.NameFormat)},
+		{"media-dir", "BOOKLAND_MEDIA_DIR", "media_dir", "directory uploaded posters are stored in", (*stringValue)(&c.MediaDir)},
+		{"media-url", "BOOKLAND_MEDIA_URL", "media_url", "URL path uploaded posters are served under", (*stringValue)(&c.MediaURL)},
+		{"db-driver", "BOOKLAND_DB_DRIVER", "db_driver", "database driver: sqlite3 or postgres", (*stringValue)(&c.DB.Driver)},
+		{"db-dsn", "BOOKLAND_DB_DSN", "db_dsn", "SQLite database file or PostgreSQL DSN", (*stringValue)(&c.DB.DSN)},
+		{"db-journal-mode", "BOOKLAND_DB_JOURNAL_MODE", "db_journal_mode", "SQLite journal mode, e.g. WAL or DELETE", (*stringValue)(&c.DB.JournalMode)},
+		{"db-busy-timeout", "BOOKLAND_DB_BUSY_TIMEOUT", "db_busy_timeout", "how long SQLite waits for a lock", (*durationValue)(&c.DB.BusyTimeout)},
+		{"db-synchronous", "BOOKLAND_DB_SYNCHRONOUS", "db_synchronous", "SQLite synchronous level: OFF, NORMAL, FULL or EXTRA", (*stringValue)(&c.DB.Synchronous)},
+		{"db-max-open-conns", "BOOKLAND_DB_MAX_OPEN_CONNS", "db_max_open_conns", "maximum open connections, 0 for no limit", (*intValue)(&c.DB.MaxOpenConns)},
+		{"db-max-idle-conns", "BOOKLAND_DB_MAX_IDLE_CONNS", "db_max_idle_conns", "maximum idle connections", (*intValue)(&c.DB.MaxIdleConns)},
+		{"db-conn-max-lifetime", "BOOKLAND_DB_CONN_MAX_LIFETIME", "db_conn_max_lifetime", "how long a connection is reused, 0 for ever", (*durationValue)(&c.DB.ConnMaxLifetime)},
+		{"backup-dir", "BOOKLAND_BACKUP_DIR", "backup_dir", "directory database snapshots are written to", (*stringValue)(&c.Backup.Dir)},
+		{"backup-keep", "BOOKLAND_BACKUP_KEEP", "backup_keep", "number of snapshots to keep", (*intValue)(&c.Backup.Keep)},
+		{"backup-interval", "BOOKLAND_BACKUP_INTERVAL", "backup_interval", "how often the server takes a snapshot, 0 for never", (*durationValue)(&c.Backup.Interval)},
+		{"trash-retention", "BOOKLAND_TRASH_RETENTION", "trash_retention", "how long deleted books and authors can be restored", (*durationValue)(&c.Trash.Retention)},
+		{"purge-interval", "BOOKLAND_PURGE_INTERVAL", "purge_interval", "how often the server purges the trash, 0 for never", (*durationValue)(&c.Trash.PurgeInterval)},
+	}
+}
+
+// Load returns the defaults overridden by the config file, then by the
+// environment and then by the flags in args. The file is named by the
+// -config flag or ConfigEnv; its keys are those of the settings, e.g.
+// {"db_dsn": "book.db", "db_busy_timeout": "5s"}.
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	c := Default()
+
+	path := getenv(ConfigEnv)
+	fs.StringVar(&path, "config", path, "JSON config file, also read from $"+ConfigEnv)
+
+	// Flags are parsed into a scratch config first, to find the config file
+	// and to let the flags override what the file and environment set.
+	scratch := Default()
+	for _, s := range settings(&scratch) {
+		fs.Var(s.value, s.flag, s.usage+" ($"+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	all := settings(&c)
+	if path != "" {
+		if err := loadFile(path, all); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range all {
+		if value := getenv(s.env); value != "" {
+			if err := s.value.Set(value); err != nil {
+				return Config{}, fmt.Errorf("$%s: %w", s.env, err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range all {
+			if s.flag == f.Name && err == nil {
+				err = s.value.Set(f.Value.String())
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := c.Backup.Validate(); err != nil {
+		return Config{}, err
+	}
+	if err := c.Trash.Validate(); err != nil {
+		return Config{}, err
+	}
+	return c, c.DB.Validate()
+}
+
+// Validate reports the first backup setting that cannot be applied.
+func (b Backup) Validate() error {
+	switch {
+	case b.Dir == "":
+		return fmt.Errorf("backup directory is empty")
+	case b.Keep < 1:
+		return fmt.Errorf("backups to keep must be at least 1")
+	case b.Interval < 0:
+		return fmt.Errorf("backup interval must not be negative")
+	}
+	return nil
+}
+
+// Validate reports the first trash setting that cannot be applied.
+func (t Trash) Validate() error {
+	switch {
+	case t.Retention < 0:
+		return fmt.Errorf("trash retention must not be negative")
+	case t.PurgeInterval < 0:
+		return fmt.Errorf("purge interval must not be negative")
+	}
+	return nil
+}
+
+// loadFile applies the settings of a JSON config file. Values may be given
+// as strings or numbers; unknown keys are rejected.
+func loadFile(path string, all []setting) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for key, raw := range values {
+		s, ok := find(all, key)
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+
+		// Numbers are kept as written, so 1000000 is not set as "1e+06".
+		var value interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		if err := s.value.Set(fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+func find(all []setting, key string) (setting, bool) {
+	for _, s := range all {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+// IsBoolFlag lets the flag be given without a value.
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration such as 5s or 1h", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+type nameFormatValue models.NameFormat
+
+func (v *nameFormatValue) Set(s string) error {
+	format, err := models.ParseNameFormat(s)
+	if err != nil {
+		return err
+	}
+	*v = nameFormatValue(format)
+	return nil
+}
+
+func (v *nameFormatValue) String() string { return string(*v) }
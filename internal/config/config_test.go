@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bookland/internal/models"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func load(args []string, env map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("bookland", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return Load(fs, args, func(key string) string { return env[key] })
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookland.json")
+	file := `{"db_dsn": "file.db", "db_busy_timeout": "2s", "db_max_open_conns": 3, "name_format": "first_last", "addr": ":9000"}`
+	assert.NoError(t, ioutil.WriteFile(path, []byte(file), 0644))
+
+	c, err := load(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), c)
+
+	c, err = load([]string{"-config", path}, map[string]string{
+		"BOOKLAND_DB_DSN":          "env.db",
+		"BOOKLAND_DB_SYNCHRONOUS":  "FULL",
+		"BOOKLAND_DB_BUSY_TIMEOUT": "3s",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "env.db", c.DB.DSN)
+	assert.Equal(t, "FULL", c.DB.Synchronous)
+	assert.Equal(t, 3*time.Second, c.DB.BusyTimeout)
+	assert.Equal(t, 3, c.DB.MaxOpenConns)
+	assert.Equal(t, models.NameFirstLast, c.NameFormat)
+	assert.Equal(t, ":9000", c.Addr)
+	assert.Equal(t, "WAL", c.DB.JournalMode)
+
+	c, err = load([]string{"-db-dsn", "flag.db", "-db-max-open-conns", "1"}, map[string]string{
+		ConfigEnv:         path,
+		"BOOKLAND_DB_DSN": "env.db",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "flag.db", c.DB.DSN)
+	assert.Equal(t, 1, c.DB.MaxOpenConns)
+	assert.Equal(t, 2*time.Second, c.DB.BusyTimeout)
+
+	c, err = load([]string{"-backup-keep", "3"}, map[string]string{"BOOKLAND_BACKUP_INTERVAL": "6h"})
+	assert.NoError(t, err)
+	assert.Equal(t, Backup{Dir: "backups", Keep: 3, Interval: 6 * time.Hour}, c.Backup)
+
+	c, err = load([]string{"-purge-interval", "24h"}, map[string]string{"BOOKLAND_TRASH_RETENTION": "168h"})
+	assert.NoError(t, err)
+	assert.Equal(t, Trash{Retention: 7 * 24 * time.Hour, PurgeInterval: 24 * time.Hour}, c.Trash)
+}
+
+func TestLoad_FileNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookland.json")
+	file := `{"db_max_open_conns": 1000000, "backup_keep": 30, "db_dsn": "book.db"}`
+	assert.NoError(t, ioutil.WriteFile(path, []byte(file), 0644))
+
+	c, err := load([]string{"-config", path}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000000, c.DB.MaxOpenConns)
+	assert.Equal(t, 30, c.Backup.Keep)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"backup_keep": 2.5}`), 0644))
+	_, err = load([]string{"-config", path}, nil)
+	assert.Error(t, err)
+}
+
+func TestLoad_TrustActorHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookland.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"trust_actor_header": true}`), 0644))
+
+	testCases := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want bool
+	}{
+		{name: "default", want: false},
+		{name: "flag", args: []string{"-trust-actor-header"}, want: true},
+		{name: "env", env: map[string]string{"BOOKLAND_TRUST_ACTOR_HEADER": "true"}, want: true},
+		{name: "file", args: []string{"-config", path}, want: true},
+		{name: "flag over file", args: []string{"-config", path, "-trust-actor-header=false"}, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := load(tc.args, tc.env)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, c.TrustActorHeader)
+		})
+	}
+
+	_, err := load(nil, map[string]string{"BOOKLAND_TRUST_ACTOR_HEADER": "maybe"})
+	assert.Error(t, err)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.json")
+	assert.NoError(t, ioutil.WriteFile(unknown, []byte(`{"db_path": "x.db"}`), 0644))
+
+	testCases := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{name: "bad duration flag", args: []string{"-db-busy-timeout", "5"}},
+		{name: "bad number in env", env: map[string]string{"BOOKLAND_DB_MAX_IDLE_CONNS": "many"}},
+		{name: "bad name format", args: []string{"-name-format", "middle_first"}},
+		{name: "unknown driver", args: []string{"-db-driver", "mysql"}},
+		{name: "unknown journal mode", env: map[string]string{"BOOKLAND_DB_JOURNAL_MODE": "FAST"}},
+		{name: "no backups kept", args: []string{"-backup-keep", "0"}},
+		{name: "negative backup interval", env: map[string]string{"BOOKLAND_BACKUP_INTERVAL": "-1h"}},
+		{name: "negative trash retention", args: []string{"-trash-retention", "-24h"}},
+		{name: "unknown file key", args: []string{"-config", unknown}},
+		{name: "missing file", args: []string{"-config", filepath.Join(dir, "missing.json")}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(tc.args, tc.env)
+			assert.Error(t, err)
+		})
+	}
+}
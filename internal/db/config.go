@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// Config describes the database connection. JournalMode, BusyTimeout and
+// Synchronous only apply to SQLite; the pool settings apply to both drivers.
+type Config struct {
+	Driver          string
+	DSN             string
+	JournalMode     string
+	BusyTimeout     time.Duration
+	Synchronous     string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultConfig returns the settings used for anything not configured:
+// book.db next to the binary in WAL mode.
+func DefaultConfig() Config {
+	return Config{
+		Driver:          DriverSQLite,
+		DSN:             "book.db",
+		JournalMode:     "WAL",
+		BusyTimeout:     5 * time.Second,
+		Synchronous:     "NORMAL",
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+	}
+}
+
+var (
+	journalModes      = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	synchronousLevels = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reports the first setting that cannot be applied.
+func (c Config) Validate() error {
+	switch {
+	case c.Driver != DriverSQLite && c.Driver != DriverPostgres:
+		return fmt.Errorf("unknown database driver %q, want %s or %s", c.Driver, DriverSQLite, DriverPostgres)
+	case c.DSN == "":
+		return fmt.Errorf("database DSN is empty")
+	case !oneOf(c.JournalMode, journalModes):
+		return fmt.Errorf("unknown journal mode %q, want one of %s", c.JournalMode, strings.Join(journalModes, ", "))
+	case !oneOf(c.Synchronous, synchronousLevels):
+		return fmt.Errorf("unknown synchronous level %q, want one of %s", c.Synchronous, strings.Join(synchronousLevels, ", "))
+	case c.BusyTimeout < 0:
+		return fmt.Errorf("busy timeout must not be negative")
+	case c.MaxOpenConns < 0 || c.MaxIdleConns < 0:
+		return fmt.Errorf("pool sizes must not be negative")
+	case c.ConnMaxLifetime < 0:
+		return fmt.Errorf("connection lifetime must not be negative")
+	}
+	return nil
+}
+
+// redactDSN hides the password of a PostgreSQL DSN.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			return u.String()
+		}
+	}
+
+	fields := strings.Fields(dsn)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "password=") {
+			fields[i] = "password=xxxxx"
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// sqliteDSN adds the PRAGMAs of c to the DSN as go-sqlite3 parameters, which
+// the driver applies to every connection it opens, before connectSQLite.
+// Parameters already in the DSN take precedence.
+func (c Config) sqliteDSN() string {
+	params := url.Values{}
+	params.Set("_journal_mode", strings.ToUpper(c.JournalMode))
+	params.Set("_busy_timeout", fmt.Sprint(c.BusyTimeout.Milliseconds()))
+	params.Set("_synchronous", strings.ToUpper(c.Synchronous))
+
+	separator := "?"
+	if strings.Contains(c.DSN, "?") {
+		separator = "&"
+	}
+	return c.DSN + separator + params.Encode()
+}
+
+// Open connects to the database c describes and configures the pool.
+func Open(c Config) (*sql.DB, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var db *sql.DB
+	var err error
+	switch c.Driver {
+	case DriverSQLite:
+		if db, err = openSQLite(c.sqliteDSN()); err != nil {
+			return nil, fmt.Errorf("open SQLite database %s: %w", c.DSN, err)
+		}
+	case DriverPostgres:
+		if db, err = NewPostgresDB(c.DSN); err != nil {
+			return nil, err
+		}
+	}
+
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	return db, nil
+}
+
+// Describe reports the settings a connection of db actually runs with, as
+// the database reports them, for logging at startup.
+func Describe(db *sql.DB, c Config) (string, error) {
+	settings := fmt.Sprintf("driver=%s dsn=%s", c.Driver, redactDSN(c.DSN))
+	if c.Driver == DriverSQLite {
+		var journalMode string
+		var busyTimeout, synchronous, foreignKeys int
+		row := db.QueryRow("SELECT * FROM pragma_journal_mode, pragma_busy_timeout, pragma_synchronous, pragma_foreign_keys")
+		if err := row.Scan(&journalMode, &busyTimeout, &synchronous, &foreignKeys); err != nil {
+			return "", err
+		}
+
+		level := fmt.Sprint(synchronous)
+		if synchronous >= 0 && synchronous < len(synchronousLevels) {
+			level = synchronousLevels[synchronous]
+		}
+		settings += fmt.Sprintf(" journal_mode=%s busy_timeout=%s synchronous=%s foreign_keys=%t",
+			strings.ToUpper(journalMode), time.Duration(busyTimeout)*time.Millisecond, level, foreignKeys == 1)
+	}
+
+	return settings + fmt.Sprintf(" max_open_conns=%d max_idle_conns=%d conn_max_lifetime=%s",
+		db.Stats().MaxOpenConnections, c.MaxIdleConns, c.ConnMaxLifetime), nil
+}
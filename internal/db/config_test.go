@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpen_SettingsOnEveryConnection(t *testing.T) {
+	c := DefaultConfig()
+	c.DSN = filepath.Join(t.TempDir(), "book.db")
+	c.BusyTimeout = 1500 * time.Millisecond
+	c.Synchronous = "FULL"
+	c.MaxOpenConns = 4
+	conn, err := Open(c)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	// Hold every connection of the pool at once so each one is checked.
+	ctx := context.Background()
+	var held []*sql.Conn
+	for i := 0; i < c.MaxOpenConns; i++ {
+		pooled, err := conn.Conn(ctx)
+		if !assert.NoError(t, err) {
+			break
+		}
+		held = append(held, pooled)
+
+		var journalMode string
+		var busyTimeout, synchronous, foreignKeys int
+		row := pooled.QueryRowContext(ctx, "SELECT * FROM pragma_journal_mode, pragma_busy_timeout, pragma_synchronous, pragma_foreign_keys")
+		assert.NoError(t, row.Scan(&journalMode, &busyTimeout, &synchronous, &foreignKeys))
+		assert.Equal(t, "wal", journalMode)
+		assert.Equal(t, 1500, busyTimeout)
+		assert.Equal(t, 2, synchronous)
+		assert.Equal(t, 1, foreignKeys)
+	}
+	assert.Equal(t, c.MaxOpenConns, conn.Stats().OpenConnections)
+	for _, pooled := range held {
+		assert.NoError(t, pooled.Close())
+	}
+
+	settings, err := Describe(conn, c)
+	assert.NoError(t, err)
+	assert.Contains(t, settings, "journal_mode=WAL busy_timeout=1.5s synchronous=FULL foreign_keys=true max_open_conns=4")
+}
+
+func TestRedactDSN(t *testing.T) {
+	assert.Equal(t, "postgres://app:xxxxx@db/bookland", redactDSN("postgres://app:secret@db/bookland"))
+	assert.Equal(t, "host=db user=app password=xxxxx", redactDSN("host=db user=app password=secret"))
+	assert.Equal(t, "book.db", redactDSN("book.db"))
+}
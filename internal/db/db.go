@@ -1,24 +1,91 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
-	"log"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"time"
 )
 
+// openRetry is how often and how patiently opening a SQLite database is
+// retried while another process holds a lock on the file.
+var openRetry = struct {
+	attempts int
+	delay    time.Duration
+	maxDelay time.Duration
+}{attempts: 5, delay: 100 * time.Millisecond, maxDelay: 2 * time.Second}
+
+// NewSQLiteDB opens the SQLite database file dbName, retrying with backoff
+// while the file is locked.
 func NewSQLiteDB(dbName string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbName)
+	db, err := openSQLite(dbName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("open SQLite database %s: %w", dbName, err)
 	}
+	return db, nil
+}
 
-	if err = db.Ping(); err != nil {
-		log.Fatal(err)
+func openSQLite(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriver, dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
-		log.Fatal(err)
+	if err := retryLocked(func() error { return checkSQLite(db) }); err != nil {
+		db.Close()
+		return nil, err
 	}
-
 	return db, nil
 }
+
+// checkSQLite reads the schema, which needs the shared lock a plain ping
+// does not take.
+func checkSQLite(db *sql.DB) error {
+	var tables int
+	return db.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&tables)
+}
+
+// retryLocked calls fn until it succeeds, fails for a reason other than a
+// locked database, or runs out of attempts, doubling the delay in between.
+func retryLocked(fn func() error) error {
+	delay := openRetry.delay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !IsLocked(err) || attempt == openRetry.attempts {
+			break
+		}
+
+		time.Sleep(delay)
+		if delay *= 2; delay > openRetry.maxDelay {
+			delay = openRetry.maxDelay
+		}
+	}
+	if err != nil && IsLocked(err) {
+		return fmt.Errorf("still locked after %d attempts: %w", openRetry.attempts, err)
+	}
+	return err
+}
+
+// IsLocked reports whether err is SQLite's busy or locked error.
+func IsLocked(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+// HealthCheck reports whether the database answers queries within ctx.
+func HealthCheck(ctx context.Context, db *sql.DB) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("query database: %w", err)
+	}
+	return nil
+}
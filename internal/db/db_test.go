@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lockSQLite holds an exclusive lock on the database file until the
+// returned function is called.
+func lockSQLite(t *testing.T, path string) func() {
+	t.Helper()
+
+	conn, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("CREATE TABLE IF NOT EXISTS t(id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// BEGIN EXCLUSIVE on the pool's only connection keeps every other
+	// connection from reading in rollback journal mode.
+	if _, err := conn.Exec("BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		conn.Exec("ROLLBACK")
+		conn.Close()
+	}
+}
+
+func withOpenRetry(t *testing.T, attempts int, delay time.Duration) {
+	saved := openRetry
+	openRetry.attempts, openRetry.delay, openRetry.maxDelay = attempts, delay, delay
+	t.Cleanup(func() { openRetry = saved })
+}
+
+func TestNewSQLiteDB_Locked(t *testing.T) {
+	withOpenRetry(t, 3, 10*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "book.db")
+	unlock := lockSQLite(t, path)
+	defer unlock()
+
+	// go-sqlite3 waits 5s for a lock by default; retrying is what is tested.
+	_, err := NewSQLiteDB(path + "?_busy_timeout=10")
+	assert.Error(t, err)
+	assert.True(t, IsLocked(err), err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+}
+
+func TestNewSQLiteDB_RetriesUntilUnlocked(t *testing.T) {
+	withOpenRetry(t, 20, 20*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "book.db")
+	unlock := lockSQLite(t, path)
+	time.AfterFunc(100*time.Millisecond, unlock)
+
+	conn, err := NewSQLiteDB(path + "?_busy_timeout=10")
+	if assert.NoError(t, err) {
+		assert.NoError(t, HealthCheck(context.Background(), conn))
+		conn.Close()
+	}
+}
+
+func TestNewSQLiteDB_Error(t *testing.T) {
+	_, err := NewSQLiteDB(filepath.Join(t.TempDir(), "missing", "book.db"))
+	assert.Error(t, err)
+	assert.False(t, IsLocked(err))
+	assert.Contains(t, err.Error(), "open SQLite database")
+}
+
+func TestHealthCheck(t *testing.T) {
+	conn, err := NewSQLiteDB(filepath.Join(t.TempDir(), "book.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, HealthCheck(context.Background(), conn))
+
+	conn.Close()
+	assert.Error(t, HealthCheck(context.Background(), conn))
+}
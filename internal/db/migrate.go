@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database"
+	"github.com/golang-migrate/migrate/database/postgres"
+	"github.com/golang-migrate/migrate/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/source/file"
+	"path/filepath"
+	"runtime"
+)
+
+// migrations returns the source URL of the migrations for driver. They are
+// found next to this file rather than relative to the working directory, so
+// it works from the tests of any package.
+func migrations(driver string) string {
+	dir := "../db/migrations"
+	if _, file, _, ok := runtime.Caller(0); ok && filepath.IsAbs(file) {
+		dir = filepath.Join(filepath.Dir(file), "migrations")
+	}
+	if driver == DriverPostgres {
+		dir = filepath.Join(dir, "postgres")
+	}
+	return "file://" + filepath.ToSlash(dir)
+}
+
+// Migrate migrates db, a database of driver, to the latest version. The
+// migrations are read from the source tree, so this is meant for tests and
+// development tools run from it. A database that is up to date
+// already is left as is, and db is left open.
+func Migrate(db *sql.DB, driver string) error {
+	var instance database.Driver
+	var err error
+	if driver == DriverPostgres {
+		instance, err = postgres.WithInstance(db, &postgres.Config{})
+	} else {
+		instance, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(migrations(driver), driver, instance)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+CREATE TABLE author_old(
+                       id INTEGER AUTO_INCREMENT PRIMARY KEY,
+                       last_name VARCHAR NOT NULL,
+                       first_name VARCHAR NOT NULL,
+                       birthday DATE NOT NULL,
+                       bio TEXT
+);
+INSERT INTO author_old(id, last_name, first_name, birthday, bio) SELECT id, last_name, first_name, birthday, bio FROM author;
+
+CREATE TABLE genre_old(
+                      id INTEGER AUTO_INCREMENT PRIMARY KEY,
+                      name VARCHAR NOT NULL
+);
+INSERT INTO genre_old(id, name) SELECT id, name FROM genre;
+
+CREATE TABLE book_old(
+                     id INTEGER AUTO_INCREMENT PRIMARY KEY,
+                     name VARCHAR NOT NULL,
+                     released DATE NOT NULL,
+                     coast INTEGER NOT NULL,
+                     pages INTEGER NOT NULL,
+                     poster VARCHAR NOT NULL,
+                     author_id INTEGER REFERENCES author_old(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                     genre_id INTEGER REFERENCES genre_old(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO book_old(id, name, released, coast, pages, poster, author_id, genre_id)
+SELECT id, name, released, coast, pages, poster, author_id, genre_id FROM book;
+
+DROP TABLE book;
+DROP TABLE author;
+DROP TABLE genre;
+
+ALTER TABLE author_old RENAME TO author;
+ALTER TABLE genre_old RENAME TO genre;
+ALTER TABLE book_old RENAME TO book;
@@ -0,0 +1,35 @@
+CREATE TABLE author_new(
+                       id INTEGER PRIMARY KEY AUTOINCREMENT,
+                       last_name VARCHAR NOT NULL,
+                       first_name VARCHAR NOT NULL,
+                       birthday DATE NOT NULL,
+                       bio TEXT
+);
+INSERT INTO author_new(id, last_name, first_name, birthday, bio) SELECT id, last_name, first_name, birthday, bio FROM author;
+
+CREATE TABLE genre_new(
+                      id INTEGER PRIMARY KEY AUTOINCREMENT,
+                      name VARCHAR NOT NULL
+);
+INSERT INTO genre_new(id, name) SELECT id, name FROM genre;
+
+CREATE TABLE book_new(
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     name VARCHAR NOT NULL,
+                     released DATE NOT NULL,
+                     coast INTEGER NOT NULL,
+                     pages INTEGER NOT NULL,
+                     poster VARCHAR NOT NULL,
+                     author_id INTEGER REFERENCES author_new(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                     genre_id INTEGER REFERENCES genre_new(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO book_new(id, name, released, coast, pages, poster, author_id, genre_id)
+SELECT id, name, released, coast, pages, poster, author_id, genre_id FROM book;
+
+DROP TABLE book;
+DROP TABLE author;
+DROP TABLE genre;
+
+ALTER TABLE author_new RENAME TO author;
+ALTER TABLE genre_new RENAME TO genre;
+ALTER TABLE book_new RENAME TO book;
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS book_isbn_uindex;
+
+CREATE TABLE book_old(
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     name VARCHAR NOT NULL,
+                     released DATE NOT NULL,
+                     coast INTEGER NOT NULL,
+                     pages INTEGER NOT NULL,
+                     poster VARCHAR NOT NULL,
+                     author_id INTEGER REFERENCES author(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                     genre_id INTEGER REFERENCES genre(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO book_old(id, name, released, coast, pages, poster, author_id, genre_id)
+SELECT id, name, released, coast, pages, poster, author_id, genre_id FROM book;
+
+DROP TABLE book;
+ALTER TABLE book_old RENAME TO book;
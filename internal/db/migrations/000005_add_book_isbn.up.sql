@@ -0,0 +1,2 @@
+ALTER TABLE book ADD COLUMN isbn VARCHAR;
+CREATE UNIQUE INDEX book_isbn_uindex ON book(isbn);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS edition;
\ No newline at end of file
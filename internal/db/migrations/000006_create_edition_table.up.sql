@@ -0,0 +1,15 @@
+CREATE TABLE edition(
+                        id INTEGER PRIMARY KEY AUTOINCREMENT,
+                        book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                        format VARCHAR NOT NULL,
+                        isbn VARCHAR,
+                        released DATE NOT NULL,
+                        coast INTEGER NOT NULL,
+                        pages INTEGER NOT NULL,
+                        poster VARCHAR NOT NULL
+);
+CREATE INDEX edition_book_id_index ON edition(book_id);
+CREATE UNIQUE INDEX edition_isbn_uindex ON edition(isbn);
+
+INSERT INTO edition(book_id, format, isbn, released, coast, pages, poster)
+SELECT id, 'unspecified', isbn, released, coast, pages, poster FROM book;
@@ -0,0 +1,14 @@
+CREATE TABLE series(
+                       id INTEGER PRIMARY KEY AUTOINCREMENT,
+                       name VARCHAR NOT NULL,
+                       description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE series_book(
+                            series_id INTEGER NOT NULL REFERENCES series(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                            book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                            position REAL NOT NULL,
+                            PRIMARY KEY (series_id, book_id),
+                            UNIQUE (series_id, position)
+);
+CREATE INDEX series_book_book_id_index ON series_book(book_id);
@@ -0,0 +1,6 @@
+ALTER TABLE genre DROP COLUMN updated_at;
+ALTER TABLE genre DROP COLUMN version;
+ALTER TABLE author DROP COLUMN updated_at;
+ALTER TABLE author DROP COLUMN version;
+ALTER TABLE book DROP COLUMN updated_at;
+ALTER TABLE book DROP COLUMN version;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE book ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE book ADD COLUMN updated_at DATETIME;
+ALTER TABLE author ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE author ADD COLUMN updated_at DATETIME;
+ALTER TABLE genre ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE genre ADD COLUMN updated_at DATETIME;
@@ -0,0 +1,10 @@
+CREATE TABLE audit_log(
+                          id INTEGER PRIMARY KEY AUTOINCREMENT,
+                          entity VARCHAR NOT NULL,
+                          entity_id INTEGER NOT NULL,
+                          action VARCHAR NOT NULL,
+                          actor VARCHAR NOT NULL,
+                          created_at DATETIME NOT NULL,
+                          diff TEXT NOT NULL
+);
+CREATE INDEX audit_log_entity_index ON audit_log(entity, entity_id);
@@ -0,0 +1,4 @@
+DROP INDEX author_deleted_at_index;
+DROP INDEX book_deleted_at_index;
+ALTER TABLE author DROP COLUMN deleted_at;
+ALTER TABLE book DROP COLUMN deleted_at;
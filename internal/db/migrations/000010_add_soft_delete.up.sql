@@ -0,0 +1,4 @@
+ALTER TABLE book ADD COLUMN deleted_at DATETIME;
+ALTER TABLE author ADD COLUMN deleted_at DATETIME;
+CREATE INDEX book_deleted_at_index ON book (deleted_at);
+CREATE INDEX author_deleted_at_index ON author (deleted_at);
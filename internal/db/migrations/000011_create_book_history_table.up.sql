@@ -0,0 +1,20 @@
+CREATE TABLE book_history(
+                             id INTEGER PRIMARY KEY AUTOINCREMENT,
+                             book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                             valid_from DATETIME,
+                             valid_to DATETIME,
+                             version INTEGER NOT NULL,
+                             name VARCHAR NOT NULL,
+                             isbn VARCHAR,
+                             released DATE NOT NULL,
+                             coast INTEGER NOT NULL,
+                             pages INTEGER NOT NULL,
+                             poster VARCHAR NOT NULL,
+                             author_id INTEGER NOT NULL,
+                             genre_id INTEGER NOT NULL
+);
+CREATE INDEX book_history_book_id_index ON book_history(book_id, valid_from);
+-- History starts now: the current state of existing books has no known
+-- beginning, which a NULL valid_from represents.
+INSERT INTO book_history(book_id, valid_from, valid_to, version, name, isbn, released, coast, pages, poster, author_id, genre_id)
+SELECT id, NULL, deleted_at, version, name, isbn, released, coast, pages, poster, author_id, genre_id FROM book;
@@ -0,0 +1,2 @@
+ALTER TABLE author DROP COLUMN pen_name;
+ALTER TABLE author DROP COLUMN middle_name;
@@ -0,0 +1,2 @@
+ALTER TABLE author ADD COLUMN middle_name VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE author ADD COLUMN pen_name VARCHAR NOT NULL DEFAULT '';
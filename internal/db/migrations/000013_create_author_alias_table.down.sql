@@ -0,0 +1 @@
+DROP TABLE author_alias;
@@ -0,0 +1,7 @@
+CREATE TABLE author_alias(
+                             id INTEGER PRIMARY KEY AUTOINCREMENT,
+                             author_id INTEGER NOT NULL REFERENCES author(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                             name VARCHAR NOT NULL
+);
+CREATE UNIQUE INDEX author_alias_author_id_name_uindex ON author_alias(author_id, name);
+CREATE INDEX author_alias_name_index ON author_alias(name);
@@ -0,0 +1,7 @@
+DROP INDEX author_slug_uindex;
+ALTER TABLE author DROP COLUMN slug;
+ALTER TABLE author DROP COLUMN social_links;
+ALTER TABLE author DROP COLUMN website;
+ALTER TABLE author DROP COLUMN portrait;
+ALTER TABLE author DROP COLUMN nationality;
+ALTER TABLE author DROP COLUMN death_day;
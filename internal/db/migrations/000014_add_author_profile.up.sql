@@ -0,0 +1,7 @@
+ALTER TABLE author ADD COLUMN death_day DATETIME;
+ALTER TABLE author ADD COLUMN nationality VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE author ADD COLUMN portrait VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE author ADD COLUMN website VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE author ADD COLUMN social_links TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE author ADD COLUMN slug VARCHAR;
+CREATE UNIQUE INDEX author_slug_uindex ON author(slug);
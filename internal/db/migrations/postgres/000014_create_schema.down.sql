@@ -0,0 +1,9 @@
+DROP TABLE book_history;
+DROP TABLE audit_log;
+DROP TABLE series_book;
+DROP TABLE series;
+DROP TABLE edition;
+DROP TABLE book;
+DROP TABLE genre;
+DROP TABLE author_alias;
+DROP TABLE author;
@@ -0,0 +1,111 @@
+-- PostgreSQL starts from the schema the SQLite migrations had reached at
+-- version 14. Later migrations are added to both directories with the same
+-- version numbers.
+CREATE TABLE author(
+                       id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                       last_name VARCHAR NOT NULL,
+                       first_name VARCHAR NOT NULL,
+                       middle_name VARCHAR NOT NULL DEFAULT '',
+                       pen_name VARCHAR NOT NULL DEFAULT '',
+                       birthday TIMESTAMPTZ NOT NULL,
+                       death_day TIMESTAMPTZ,
+                       bio TEXT,
+                       nationality VARCHAR NOT NULL DEFAULT '',
+                       portrait VARCHAR NOT NULL DEFAULT '',
+                       website VARCHAR NOT NULL DEFAULT '',
+                       social_links TEXT NOT NULL DEFAULT '[]',
+                       slug VARCHAR,
+                       version INTEGER NOT NULL DEFAULT 1,
+                       updated_at TIMESTAMPTZ,
+                       deleted_at TIMESTAMPTZ
+);
+CREATE INDEX author_deleted_at_index ON author(deleted_at);
+CREATE UNIQUE INDEX author_slug_uindex ON author(slug);
+
+CREATE TABLE author_alias(
+                             id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                             author_id BIGINT NOT NULL REFERENCES author(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                             name VARCHAR NOT NULL
+);
+CREATE UNIQUE INDEX author_alias_author_id_name_uindex ON author_alias(author_id, name);
+CREATE INDEX author_alias_name_index ON author_alias(name);
+
+CREATE TABLE genre(
+                      id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                      name VARCHAR NOT NULL,
+                      version INTEGER NOT NULL DEFAULT 1,
+                      updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE book(
+                     id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                     name VARCHAR NOT NULL,
+                     isbn VARCHAR,
+                     released TIMESTAMPTZ NOT NULL,
+                     coast INTEGER NOT NULL,
+                     pages INTEGER NOT NULL,
+                     poster VARCHAR NOT NULL,
+                     author_id BIGINT REFERENCES author(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                     genre_id BIGINT REFERENCES genre(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                     version INTEGER NOT NULL DEFAULT 1,
+                     updated_at TIMESTAMPTZ,
+                     deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX book_isbn_uindex ON book(isbn);
+CREATE INDEX book_deleted_at_index ON book(deleted_at);
+
+CREATE TABLE edition(
+                        id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                        book_id BIGINT NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                        format VARCHAR NOT NULL,
+                        isbn VARCHAR,
+                        released TIMESTAMPTZ NOT NULL,
+                        coast INTEGER NOT NULL,
+                        pages INTEGER NOT NULL,
+                        poster VARCHAR NOT NULL
+);
+CREATE INDEX edition_book_id_index ON edition(book_id);
+CREATE UNIQUE INDEX edition_isbn_uindex ON edition(isbn);
+
+CREATE TABLE series(
+                       id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                       name VARCHAR NOT NULL,
+                       description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE series_book(
+                            series_id BIGINT NOT NULL REFERENCES series(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                            book_id BIGINT NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                            position DOUBLE PRECISION NOT NULL,
+                            PRIMARY KEY (series_id, book_id),
+                            UNIQUE (series_id, position)
+);
+CREATE INDEX series_book_book_id_index ON series_book(book_id);
+
+CREATE TABLE audit_log(
+                          id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                          entity VARCHAR NOT NULL,
+                          entity_id BIGINT NOT NULL,
+                          action VARCHAR NOT NULL,
+                          actor VARCHAR NOT NULL,
+                          created_at TIMESTAMPTZ NOT NULL,
+                          diff TEXT NOT NULL
+);
+CREATE INDEX audit_log_entity_index ON audit_log(entity, entity_id);
+
+CREATE TABLE book_history(
+                             id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                             book_id BIGINT NOT NULL REFERENCES book(id) ON DELETE CASCADE ON UPDATE CASCADE,
+                             valid_from TIMESTAMPTZ,
+                             valid_to TIMESTAMPTZ,
+                             version INTEGER NOT NULL,
+                             name VARCHAR NOT NULL,
+                             isbn VARCHAR,
+                             released TIMESTAMPTZ NOT NULL,
+                             coast INTEGER NOT NULL,
+                             pages INTEGER NOT NULL,
+                             poster VARCHAR NOT NULL,
+                             author_id BIGINT NOT NULL,
+                             genre_id BIGINT NOT NULL
+);
+CREATE INDEX book_history_book_id_index ON book_history(book_id, valid_from);
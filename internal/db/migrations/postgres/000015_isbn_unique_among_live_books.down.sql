@@ -0,0 +1,5 @@
+DROP INDEX edition_isbn_uindex;
+ALTER TABLE edition DROP COLUMN deleted_at;
+CREATE UNIQUE INDEX edition_isbn_uindex ON edition(isbn);
+DROP INDEX book_isbn_uindex;
+CREATE UNIQUE INDEX book_isbn_uindex ON book(isbn);
@@ -0,0 +1,8 @@
+DROP INDEX book_isbn_uindex;
+CREATE UNIQUE INDEX book_isbn_uindex ON book(isbn) WHERE deleted_at IS NULL;
+-- Editions carry their book's deleted_at, so their ISBNs stay unique among
+-- live books while a book in the trash gives its ISBNs up.
+ALTER TABLE edition ADD COLUMN deleted_at TIMESTAMPTZ;
+UPDATE edition SET deleted_at = (SELECT deleted_at FROM book WHERE book.id = edition.book_id);
+DROP INDEX edition_isbn_uindex;
+CREATE UNIQUE INDEX edition_isbn_uindex ON edition(isbn) WHERE deleted_at IS NULL;
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver is the go-sqlite3 driver registered with connectSQLite as its
+// ConnectHook. Every *sql.DB for SQLite is opened with it, so each pooled
+// connection is prepared the same way and not only the one that happens to
+// serve a statement run on the pool.
+const sqliteDriver = "sqlite3_bookland"
+
+// sqlitePragmas are set on every new connection. The cascading deletes and
+// the foreign keys of the schema are only enforced with foreign_keys on.
+var sqlitePragmas = []string{
+	"PRAGMA foreign_keys = ON",
+}
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{ConnectHook: connectSQLite})
+}
+
+// connectSQLite applies sqlitePragmas to a new connection and checks that
+// foreign keys are enforced, since SQLite ignores the PRAGMA when it is
+// built without foreign key support.
+func connectSQLite(conn *sqlite3.SQLiteConn) error {
+	for _, pragma := range sqlitePragmas {
+		if _, err := conn.Exec(pragma, nil); err != nil {
+			return fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+
+	rows, err := conn.Query("PRAGMA foreign_keys", nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, 1)
+	if err := rows.Next(values); err != nil {
+		return err
+	}
+	if enabled, ok := values[0].(int64); !ok || enabled != 1 {
+		return fmt.Errorf("foreign keys could not be enabled")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSQLiteDriver_ForeignKeysOnEveryConnection(t *testing.T) {
+	const connections = 16
+
+	c := DefaultConfig()
+	c.DSN = filepath.Join(t.TempDir(), "fk.db")
+	c.MaxOpenConns = connections
+	c.MaxIdleConns = connections
+	conn, err := Open(c)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Exec(`
+		CREATE TABLE parent(id INTEGER PRIMARY KEY);
+		CREATE TABLE child(id INTEGER PRIMARY KEY, parent_id INTEGER NOT NULL REFERENCES parent(id) ON DELETE CASCADE);
+		INSERT INTO parent(id) VALUES (1);`)
+	assert.NoError(t, err)
+
+	// Hold all connections at once, so every one of them is a separate
+	// connection of the pool, then use them concurrently.
+	ctx := context.Background()
+	held := make([]*sql.Conn, 0, connections)
+	for i := 0; i < connections; i++ {
+		pooled, err := conn.Conn(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		held = append(held, pooled)
+	}
+	assert.Equal(t, connections, conn.Stats().OpenConnections)
+
+	var wg sync.WaitGroup
+	errs := make([]error, connections)
+	for i, pooled := range held {
+		wg.Add(1)
+		go func(i int, pooled *sql.Conn) {
+			defer wg.Done()
+			_, errs[i] = pooled.ExecContext(ctx, "INSERT INTO child(id, parent_id) VALUES (?, 99)", i+1)
+		}(i, pooled)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if assert.Error(t, err, "connection %d", i) {
+			assert.True(t, strings.Contains(err.Error(), "FOREIGN KEY constraint failed"), err.Error())
+		}
+	}
+
+	// The cascade runs on whichever connection deletes the parent.
+	_, err = held[0].ExecContext(ctx, "INSERT INTO child(id, parent_id) VALUES (100, 1)")
+	assert.NoError(t, err)
+	_, err = held[connections-1].ExecContext(ctx, "DELETE FROM parent WHERE id = 1")
+	assert.NoError(t, err)
+
+	var children int
+	assert.NoError(t, held[connections/2].QueryRowContext(ctx, "SELECT COUNT(id) FROM child").Scan(&children))
+	assert.Equal(t, 0, children)
+
+	for _, pooled := range held {
+		assert.NoError(t, pooled.Close())
+	}
+}
+
+func TestNewSQLiteDB_ForeignKeys(t *testing.T) {
+	conn, err := NewSQLiteDB(filepath.Join(t.TempDir(), "book.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(4)
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		pooled, err := conn.Conn(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer pooled.Close()
+
+		var enabled int
+		assert.NoError(t, pooled.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled))
+		assert.Equal(t, 1, enabled)
+	}
+}
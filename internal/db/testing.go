@@ -2,83 +2,143 @@ package db
 
 import (
 	"database/sql"
-	"github.com/golang-migrate/migrate"
-	"github.com/golang-migrate/migrate/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/source/file"
-	_ "github.com/mattn/go-sqlite3"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
-func NewTestSQLiteDB(t *testing.T) *sql.DB {
-	t.Helper()
-
-	log.SetFlags(log.Lshortfile)
+// TestPostgresDSNEnv names the environment variable holding the DSN of a
+// PostgreSQL database for tests. Every test creates a schema of its own in
+// it and drops the schema when it ends.
+const TestPostgresDSNEnv = "BOOKLAND_TEST_POSTGRES_DSN"
 
-	db, err := sql.Open("sqlite3", "test.db")
-	if err != nil {
-		t.Fatal()
+// TestDriver returns the driver of the databases NewTestDB returns:
+// PostgreSQL when TestPostgresDSNEnv is set and SQLite otherwise.
+func TestDriver() string {
+	if os.Getenv(TestPostgresDSNEnv) != "" {
+		return DriverPostgres
 	}
+	return DriverSQLite
+}
 
-	if err = db.Ping(); err != nil {
-		t.Fatal()
+// NewTestDB returns an empty migrated database of TestDriver for the test
+// alone; fixture.NewTestDB also fills it with the data the tests share. It
+// is removed when the test ends, so tests using it may run in parallel.
+func NewTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if TestDriver() == DriverPostgres {
+		return NewTestPostgresDB(t)
 	}
+	return NewTestSQLiteDB(t)
+}
 
-	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
-		DropTestSQLiteDB(t)
-		t.Fatal()
+// testSchemas numbers the PostgreSQL schemas of the tests of this process.
+var testSchemas int64
+
+// NewTestPostgresDB returns a connection to a new schema of the database
+// named by TestPostgresDSNEnv, migrated to the latest version. The schema is dropped when the test ends. The test is skipped
+// when the variable is not set.
+func NewTestPostgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(TestPostgresDSNEnv)
+	if dsn == "" {
+		t.Skip(TestPostgresDSNEnv + " is not set")
 	}
 
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	admin, err := NewPostgresDB(dsn)
 	if err != nil {
-		t.Fatal()
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("test_%d_%d", os.Getpid(), atomic.AddInt64(&testSchemas, 1))
+	if _, err := admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE; CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
 	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+		admin.Close()
+	})
 
-	m, err := migrate.NewWithDatabaseInstance("file://../db/migrations", "test.db", driver)
+	dsn, err = withParam(dsn, "search_path", schema)
 	if err != nil {
-		log.Printf("%s\n", err)
-		t.Fatal()
+		t.Fatal(err)
 	}
-
-	if err = m.Up(); err != nil {
-		t.Fatal()
+	db, err := NewPostgresDB(dsn)
+	if err != nil {
+		t.Fatal(err)
 	}
+	t.Cleanup(func() { db.Close() })
 
-	if _, err = db.Exec(testData); err != nil {
-		t.Fatal()
+	if err := Migrate(db, DriverPostgres); err != nil {
+		t.Fatal(err)
 	}
 
 	return db
 }
 
-func DropTestSQLiteDB(t *testing.T) {
+// sqliteTemplate is an in-memory database migrated once per process, which
+// NewTestSQLiteDB copies for every test. A single connection that is never
+// closed keeps the database alive.
+var sqliteTemplate struct {
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+func templateSQLiteDB() (*sql.DB, error) {
+	sqliteTemplate.once.Do(func() {
+		log.SetFlags(log.Lshortfile)
+
+		db, err := sql.Open(sqliteDriver, "file:bookland_test_template?mode=memory&cache=shared")
+		if err != nil {
+			sqliteTemplate.err = err
+			return
+		}
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+
+		if err := Migrate(db, DriverSQLite); err != nil {
+			sqliteTemplate.err = fmt.Errorf("migrate the template database: %w", err)
+			return
+		}
+		sqliteTemplate.db = db
+	})
+	return sqliteTemplate.db, sqliteTemplate.err
+}
+
+// NewTestSQLiteDB returns an empty migrated SQLite database in a directory
+// of the test's own, removed with the database when the test ends. The
+// database is a copy of a template migrated once per process.
+func NewTestSQLiteDB(t *testing.T) *sql.DB {
 	t.Helper()
 
-	if err := os.Remove("test.db"); err != nil {
-		t.Fatal()
+	template, err := templateSQLiteDB()
+	if err != nil {
+		t.Fatal(err)
 	}
-}
 
-var testData = `
-	INSERT INTO genre(id, name) VALUES (1, 'test_genre');
-	INSERT INTO genre(id, name) VALUES (2, 'test_genre 2');
-	INSERT INTO author(id, last_name, first_name, birthday, bio) VALUES (1, 'Potter', 'Harry', '03.12.1968', 'bio');
-	INSERT INTO author(id, last_name, first_name, birthday, bio) VALUES (2, 'Laurence', 'Freddy', '03.12.1982', 'bio');
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (1, 'test book 1', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (2, 'test book 2', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (3, 'test book 3', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (4, 'test book 4', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (5, 'test book 5', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (6, 'test book 6', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (7, 'test book 7', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (8, 'test book 8', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (9, 'test book 9', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (10, 'test book 10', '03.12.2019', 300, 150, 'img.png', 1, 1);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (11, 'test book 11', '03.12.2019', 300, 150, 'img.png', 2, 2);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (12, 'test book 12', '03.12.2019', 300, 150, 'img.png', 2, 2);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (13, 'test book 13', '03.12.2019', 300, 150, 'img.png', 2, 2);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (14, 'test book 14', '03.12.2019', 300, 150, 'img.png', 2, 2);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (15, 'test book 15', '03.12.2019', 300, 150, 'img.png', 2, 2);
-	INSERT INTO book(id, name, released, coast, pages, poster, author_id, genre_id) VALUES (16, 'test book 16', '03.12.2019', 300, 150, 'img.png', 2, 2);
-`
+	path := filepath.Join(t.TempDir(), "test.db")
+	if _, err := template.Exec("VACUUM INTO ?", path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewTestSQLiteDB_Isolated(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			conn := NewTestSQLiteDB(t)
+
+			var genres int
+			assert.NoError(t, conn.QueryRow("SELECT COUNT(id) FROM genre").Scan(&genres))
+			assert.Equal(t, 0, genres)
+
+			// Each test changes its own copy only.
+			_, err := conn.Exec("INSERT INTO genre(name) VALUES (?)", t.Name())
+			assert.NoError(t, err)
+			assert.NoError(t, conn.QueryRow("SELECT COUNT(id) FROM genre").Scan(&genres))
+			assert.Equal(t, 1, genres)
+		})
+	}
+
+	_, err := os.Stat("test.db")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewTestSQLiteDB_Template(t *testing.T) {
+	NewTestSQLiteDB(t)
+	template := sqliteTemplate.db
+	NewTestSQLiteDB(t)
+	assert.True(t, template == sqliteTemplate.db)
+
+	// The copies are migrated like the template.
+	var version int
+	assert.NoError(t, template.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version))
+	conn := NewTestSQLiteDB(t)
+	var copied int
+	assert.NoError(t, conn.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&copied))
+	assert.Equal(t, version, copied)
+	assert.NotZero(t, copied)
+}
+
+func TestMigrations(t *testing.T) {
+	for _, driver := range []string{DriverSQLite, DriverPostgres} {
+		source := migrations(driver)
+		assert.True(t, strings.HasPrefix(source, "file://"))
+		info, err := os.Stat(strings.TrimPrefix(source, "file://"))
+		if assert.NoError(t, err) {
+			assert.True(t, info.IsDir())
+		}
+	}
+}
+
+func TestWithParam(t *testing.T) {
+	testCases := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{name: "URL", dsn: "postgres://u:p@localhost/bookland?sslmode=disable",
+			want: "postgres://u:p@localhost/bookland?search_path=test_1&sslmode=disable"},
+		{name: "URL with the parameter", dsn: "postgresql://localhost/bookland?search_path=other",
+			want: "postgresql://localhost/bookland?search_path=other"},
+		{name: "key value", dsn: "host=localhost dbname=bookland", want: "host=localhost dbname=bookland search_path=test_1"},
+		{name: "key value with the parameter", dsn: "dbname=bookland search_path=other", want: "dbname=bookland search_path=other"},
+		{name: "empty", dsn: "", want: "search_path=test_1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dsn, err := withParam(tc.dsn, "search_path", "test_1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, dsn)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write([]string{
+		"id", "name", "isbn", "release", "coast", "pages", "poster", "author_last_name", "author_first_name", "genre",
+	})
+}
+
+func (e *csvEncoder) encode(r *record) error {
+	return e.w.Write([]string{
+		strconv.FormatInt(r.Id, 10),
+		r.Name,
+		r.ISBN,
+		r.Release,
+		strconv.FormatUint(uint64(r.Coast), 10),
+		strconv.FormatUint(uint64(r.Pages), 10),
+		r.PosterURL,
+		r.AuthorLastName,
+		r.AuthorFirstName,
+		r.GenreName,
+	})
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
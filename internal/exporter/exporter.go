@@ -0,0 +1,98 @@
+// Package exporter streams the catalogue out of the store as CSV, JSON Lines
+// or ONIX 3.0 XML.
+package exporter
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"fmt"
+	"io"
+)
+
+// DateLayout is the layout used for dates in CSV and JSON Lines output.
+// It matches importer.DateLayout so an export can be imported back.
+const DateLayout = "2006-01-02"
+
+type Format string
+
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+	ONIX  Format = "onix"
+)
+
+type Options struct {
+	// SenderName is written to the ONIX message header and as the supplier.
+	// It defaults to "Bookland".
+	SenderName string
+	// CurrencyCode is the ISO 4217 code of book prices in ONIX output.
+	CurrencyCode string
+}
+
+// record is a book with its author and genre names resolved.
+type record struct {
+	Id              int64  `json:"id"`
+	Name            string `json:"name"`
+	ISBN            string `json:"isbn,omitempty"`
+	Release         string `json:"release"`
+	Coast           uint   `json:"coast"`
+	Pages           uint   `json:"pages"`
+	PosterURL       string `json:"poster_url"`
+	AuthorId        int64  `json:"author_id"`
+	AuthorLastName  string `json:"author_last_name"`
+	AuthorFirstName string `json:"author_first_name"`
+	GenreId         int64  `json:"genre_id"`
+	GenreName       string `json:"genre_name"`
+}
+
+type encoder interface {
+	begin() error
+	encode(r *record) error
+	end() error
+}
+
+// Export writes every book in the store to w in the given format.
+// Books are streamed one at a time, read with their authors in one query.
+func Export(s *store.Store, w io.Writer, format Format, opts Options) error {
+	if opts.SenderName == "" {
+		opts.SenderName = "Bookland"
+	}
+
+	var enc encoder
+	switch format {
+	case CSV:
+		enc = newCSVEncoder(w)
+	case JSONL:
+		enc = newJSONLEncoder(w)
+	case ONIX:
+		enc = newONIXEncoder(w, opts)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+
+	err := s.Books.ForEachWithAuthor(func(b *models.Book, author *models.Author) error {
+		return enc.encode(&record{
+			Id:              b.Id,
+			Name:            b.Name,
+			ISBN:            b.ISBN,
+			Release:         b.Release.Format(DateLayout),
+			Coast:           b.Coast,
+			Pages:           b.Pages,
+			PosterURL:       b.PosterURL,
+			AuthorId:        b.AuthorId,
+			AuthorLastName:  author.LastName,
+			AuthorFirstName: author.FirstName,
+			GenreId:         b.GenreId,
+			GenreName:       b.GenreName,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return enc.end()
+}
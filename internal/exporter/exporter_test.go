@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"bookland/internal/fixture"
+	"bookland/internal/importer"
+	"bookland/internal/store"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExport_CSV(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	var buf bytes.Buffer
+	err := Export(s, &buf, CSV, Options{})
+	assert.NoError(t, err)
+
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 17, len(records))
+	assert.Equal(t, []string{"1", "test book 1", "", "2019-12-03", "300", "150", "img.png", "Potter", "Harry", "test_genre"}, records[1])
+}
+
+func TestExport_CSVRoundTrip(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.Tx(func(tx *store.Store) error {
+		return Export(tx, &buf, CSV, Options{})
+	}))
+
+	report, err := importer.Import(s, &buf, importer.Options{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 16, report.Imported)
+}
+
+func TestExport_JSONL(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	var buf bytes.Buffer
+	err := Export(s, &buf, JSONL, Options{})
+	assert.NoError(t, err)
+
+	lines := 0
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r record
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		assert.NotZero(t, r.Id)
+		assert.NotEmpty(t, r.AuthorLastName)
+		lines++
+	}
+	assert.Equal(t, 16, lines)
+}
+
+func TestExport_ONIX(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+
+	var buf bytes.Buffer
+	err := Export(s, &buf, ONIX, Options{CurrencyCode: "UAH"})
+	assert.NoError(t, err)
+
+	var message struct {
+		XMLName  xml.Name      `xml:"http://ns.editeur.org/onix/3.0/reference ONIXMessage"`
+		Release  string        `xml:"release,attr"`
+		Sender   string        `xml:"Header>Sender>SenderName"`
+		Products []onixProduct `xml:"Product"`
+	}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &message))
+	assert.Equal(t, "3.0", message.Release)
+	assert.Equal(t, "Bookland", message.Sender)
+	assert.Equal(t, 16, len(message.Products))
+
+	p := message.Products[0]
+	assert.Equal(t, "test book 1", p.DescriptiveDetail.TitleDetail.TitleElement.TitleText)
+	assert.Equal(t, "Potter", p.DescriptiveDetail.Contributor.KeyNames)
+	assert.Equal(t, uint(150), p.DescriptiveDetail.Extent.ExtentValue)
+	assert.Equal(t, uint(300), p.SupplyDetail.Price.PriceAmount)
+	assert.Equal(t, "UAH", p.SupplyDetail.Price.CurrencyCode)
+	assert.Equal(t, "img.png", p.CollateralDetail.SupportingResource.ResourceVersion.ResourceLink)
+}
+
+func TestExport_UnknownFormat(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	err := Export(store.NewStore(conn), &buf, Format("pdf"), Options{})
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *jsonlEncoder) begin() error {
+	return nil
+}
+
+// encode writes r as a single line; json.Encoder terminates every value with '\n'.
+func (e *jsonlEncoder) encode(r *record) error {
+	return e.enc.Encode(r)
+}
+
+func (e *jsonlEncoder) end() error {
+	return nil
+}
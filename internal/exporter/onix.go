@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ONIX 3.0 code list values used by the export.
+const (
+	onixNamespace = "http://ns.editeur.org/onix/3.0/reference"
+
+	onixNotificationConfirmed = "03"  // List 1: notification confirmed on publication
+	onixIDTypeProprietary     = "01"  // List 5: proprietary product identifier
+	onixIDTypeISBN13          = "15"  // List 5: ISBN-13
+	onixCompositionSingle     = "00"  // List 2: single-component retail product
+	onixFormBook              = "BA"  // List 150: book
+	onixTitleDistinctive      = "01"  // List 15: distinctive title
+	onixTitleLevelProduct     = "01"  // List 149: product level
+	onixRoleAuthor            = "A01" // List 17: by (author)
+	onixExtentMainContent     = "00"  // List 23: main content page count
+	onixExtentUnitPages       = "03"  // List 24: pages
+	onixSubjectKeywords       = "20"  // List 27: keywords
+	onixResourceFrontCover    = "01"  // List 158: front cover
+	onixAudienceUnrestricted  = "00"  // List 154: unrestricted
+	onixResourceModeImage     = "03"  // List 159: still image
+	onixResourceFormLink      = "02"  // List 161: downloadable file
+	onixDatePublication       = "01"  // List 163: publication date
+	onixSupplierUnspecified   = "00"  // List 93: unspecified
+	onixAvailable             = "20"  // List 65: available
+	onixPriceRRPExcludingTax  = "01"  // List 58: RRP excluding tax
+)
+
+type onixHeader struct {
+	XMLName      xml.Name `xml:"Header"`
+	SenderName   string   `xml:"Sender>SenderName"`
+	SentDateTime string   `xml:"SentDateTime"`
+}
+
+type onixProduct struct {
+	XMLName           xml.Name                `xml:"Product"`
+	RecordReference   string                  `xml:"RecordReference"`
+	NotificationType  string                  `xml:"NotificationType"`
+	ProductIdentifier []onixProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail struct {
+		ProductComposition string `xml:"ProductComposition"`
+		ProductForm        string `xml:"ProductForm"`
+		TitleDetail        struct {
+			TitleType    string `xml:"TitleType"`
+			TitleElement struct {
+				TitleElementLevel string `xml:"TitleElementLevel"`
+				TitleText         string `xml:"TitleText"`
+			} `xml:"TitleElement"`
+		} `xml:"TitleDetail"`
+		Contributor struct {
+			SequenceNumber  int    `xml:"SequenceNumber"`
+			ContributorRole string `xml:"ContributorRole"`
+			PersonName      string `xml:"PersonName"`
+			NamesBeforeKey  string `xml:"NamesBeforeKey,omitempty"`
+			KeyNames        string `xml:"KeyNames"`
+		} `xml:"Contributor"`
+		Extent struct {
+			ExtentType  string `xml:"ExtentType"`
+			ExtentValue uint   `xml:"ExtentValue"`
+			ExtentUnit  string `xml:"ExtentUnit"`
+		} `xml:"Extent"`
+		Subject struct {
+			SubjectSchemeIdentifier string `xml:"SubjectSchemeIdentifier"`
+			SubjectHeadingText      string `xml:"SubjectHeadingText"`
+		} `xml:"Subject"`
+	} `xml:"DescriptiveDetail"`
+	CollateralDetail *onixCollateralDetail `xml:"CollateralDetail,omitempty"`
+	PublishingDetail struct {
+		PublishingDate struct {
+			PublishingDateRole string `xml:"PublishingDateRole"`
+			Date               string `xml:"Date"`
+		} `xml:"PublishingDate"`
+	} `xml:"PublishingDetail"`
+	SupplyDetail struct {
+		SupplierRole        string `xml:"Supplier>SupplierRole"`
+		SupplierName        string `xml:"Supplier>SupplierName"`
+		ProductAvailability string `xml:"ProductAvailability"`
+		Price               struct {
+			PriceType    string `xml:"PriceType"`
+			PriceAmount  uint   `xml:"PriceAmount"`
+			CurrencyCode string `xml:"CurrencyCode,omitempty"`
+		} `xml:"Price"`
+	} `xml:"ProductSupply>SupplyDetail"`
+}
+
+type onixProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type onixCollateralDetail struct {
+	SupportingResource struct {
+		ResourceContentType string `xml:"ResourceContentType"`
+		ContentAudience     string `xml:"ContentAudience"`
+		ResourceMode        string `xml:"ResourceMode"`
+		ResourceVersion     struct {
+			ResourceForm string `xml:"ResourceForm"`
+			ResourceLink string `xml:"ResourceLink"`
+		} `xml:"ResourceVersion"`
+	} `xml:"SupportingResource"`
+}
+
+type onixEncoder struct {
+	w    io.Writer
+	enc  *xml.Encoder
+	opts Options
+	root xml.StartElement
+}
+
+func newONIXEncoder(w io.Writer, opts Options) *onixEncoder {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &onixEncoder{
+		w:    w,
+		enc:  enc,
+		opts: opts,
+		root: xml.StartElement{
+			Name: xml.Name{Local: "ONIXMessage"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "release"}, Value: "3.0"},
+				{Name: xml.Name{Local: "xmlns"}, Value: onixNamespace},
+			},
+		},
+	}
+}
+
+func (e *onixEncoder) begin() error {
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeToken(e.root); err != nil {
+		return err
+	}
+	return e.enc.Encode(&onixHeader{
+		SenderName:   e.opts.SenderName,
+		SentDateTime: time.Now().UTC().Format("20060102T1504Z"),
+	})
+}
+
+func (e *onixEncoder) encode(r *record) error {
+	p := &onixProduct{
+		RecordReference:  "bookland-" + strconv.FormatInt(r.Id, 10),
+		NotificationType: onixNotificationConfirmed,
+	}
+	p.ProductIdentifier = append(p.ProductIdentifier, onixProductIdentifier{
+		ProductIDType: onixIDTypeProprietary,
+		IDValue:       strconv.FormatInt(r.Id, 10),
+	})
+	if r.ISBN != "" {
+		p.ProductIdentifier = append(p.ProductIdentifier, onixProductIdentifier{
+			ProductIDType: onixIDTypeISBN13,
+			IDValue:       r.ISBN,
+		})
+	}
+
+	d := &p.DescriptiveDetail
+	d.ProductComposition = onixCompositionSingle
+	d.ProductForm = onixFormBook
+	d.TitleDetail.TitleType = onixTitleDistinctive
+	d.TitleDetail.TitleElement.TitleElementLevel = onixTitleLevelProduct
+	d.TitleDetail.TitleElement.TitleText = r.Name
+	d.Contributor.SequenceNumber = 1
+	d.Contributor.ContributorRole = onixRoleAuthor
+	d.Contributor.PersonName = strings.TrimSpace(r.AuthorFirstName + " " + r.AuthorLastName)
+	d.Contributor.NamesBeforeKey = r.AuthorFirstName
+	d.Contributor.KeyNames = r.AuthorLastName
+	d.Extent.ExtentType = onixExtentMainContent
+	d.Extent.ExtentValue = r.Pages
+	d.Extent.ExtentUnit = onixExtentUnitPages
+	d.Subject.SubjectSchemeIdentifier = onixSubjectKeywords
+	d.Subject.SubjectHeadingText = r.GenreName
+
+	if r.PosterURL != "" {
+		c := &onixCollateralDetail{}
+		c.SupportingResource.ResourceContentType = onixResourceFrontCover
+		c.SupportingResource.ContentAudience = onixAudienceUnrestricted
+		c.SupportingResource.ResourceMode = onixResourceModeImage
+		c.SupportingResource.ResourceVersion.ResourceForm = onixResourceFormLink
+		c.SupportingResource.ResourceVersion.ResourceLink = r.PosterURL
+		p.CollateralDetail = c
+	}
+
+	p.PublishingDetail.PublishingDate.PublishingDateRole = onixDatePublication
+	p.PublishingDetail.PublishingDate.Date = strings.Replace(r.Release, "-", "", -1)
+
+	s := &p.SupplyDetail
+	s.SupplierRole = onixSupplierUnspecified
+	s.SupplierName = e.opts.SenderName
+	s.ProductAvailability = onixAvailable
+	s.Price.PriceType = onixPriceRRPExcludingTax
+	s.Price.PriceAmount = r.Coast
+	s.Price.CurrencyCode = e.opts.CurrencyCode
+
+	return e.enc.Encode(p)
+}
+
+func (e *onixEncoder) end() error {
+	if err := e.enc.EncodeToken(e.root.End()); err != nil {
+		return err
+	}
+	if err := e.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
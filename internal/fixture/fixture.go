@@ -0,0 +1,287 @@
+// Package fixture loads sets of genres, authors and books written in YAML or
+// JSON into a database through the store, for tests and development
+// databases, and generates large synthetic sets for performance testing.
+package fixture
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the dates in fixture files.
+const DateLayout = "2006-01-02"
+
+type Format string
+
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+)
+
+// Set is the content of one fixture file. Books refer to their author by
+// the author's Ref and to their genre by name; either may also be one
+// already in the database, an author then being named by slug.
+type Set struct {
+	Genres  []Genre  `json:"genres" yaml:"genres"`
+	Authors []Author `json:"authors" yaml:"authors"`
+	Books   []Book   `json:"books" yaml:"books"`
+}
+
+type Genre struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// Author is an author of a set. Ref names the author for the books of the
+// set and later sets; it is not stored.
+type Author struct {
+	Ref         string   `json:"ref" yaml:"ref"`
+	LastName    string   `json:"last_name" yaml:"last_name"`
+	FirstName   string   `json:"first_name" yaml:"first_name"`
+	MiddleName  string   `json:"middle_name" yaml:"middle_name"`
+	PenName     string   `json:"pen_name" yaml:"pen_name"`
+	BirthDay    string   `json:"birth_day" yaml:"birth_day"`
+	DeathDay    string   `json:"death_day" yaml:"death_day"`
+	Bio         string   `json:"bio" yaml:"bio"`
+	Nationality string   `json:"nationality" yaml:"nationality"`
+	PortraitURL string   `json:"portrait_url" yaml:"portrait_url"`
+	Website     string   `json:"website" yaml:"website"`
+	SocialLinks []string `json:"social_links" yaml:"social_links"`
+	Slug        string   `json:"slug" yaml:"slug"`
+}
+
+type Book struct {
+	Name      string `json:"name" yaml:"name"`
+	ISBN      string `json:"isbn" yaml:"isbn"`
+	Release   string `json:"release" yaml:"release"`
+	Coast     uint   `json:"coast" yaml:"coast"`
+	Pages     uint   `json:"pages" yaml:"pages"`
+	PosterURL string `json:"poster_url" yaml:"poster_url"`
+	Author    string `json:"author" yaml:"author"`
+	Genre     string `json:"genre" yaml:"genre"`
+}
+
+// Parse reads a set in format. Unknown keys are rejected, so a misspelt
+// field does not silently load as empty.
+func Parse(r io.Reader, format Format) (*Set, error) {
+	set := &Set{}
+	switch format {
+	case YAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(set); err != nil && err != io.EOF {
+			return nil, err
+		}
+	case JSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(set); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown fixture format %q", format)
+	}
+	return set, nil
+}
+
+// ReadFile reads the set in the file at path, telling its format from the
+// extension: .yaml, .yml or .json.
+func ReadFile(path string) (*Set, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = YAML
+	case ".json":
+		format = JSON
+	default:
+		return nil, fmt.Errorf("%s: unknown fixture format, want .yaml, .yml or .json", path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set, err := Parse(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return set, nil
+}
+
+// Result holds what Load added, with the ids the database assigned.
+type Result struct {
+	// Genres are keyed by name and include the genres books were found in
+	// the database.
+	Genres map[string]*models.Genre
+	// Authors are keyed by Ref; authors without one are only in the database.
+	Authors map[string]*models.Author
+	Books   []*models.Book
+}
+
+// Load adds the sets to s in order in one transaction, so either all of
+// them are loaded or none. Genres already in the database are reused rather
+// than added again. Every record goes through the store, so it is validated
+// and audited as if added through the API.
+func Load(s *store.Store, sets ...*Set) (*Result, error) {
+	res := &Result{Genres: map[string]*models.Genre{}, Authors: map[string]*models.Author{}}
+	err := s.Tx(func(tx *store.Store) error {
+		for i, set := range sets {
+			if err := res.load(tx, set); err != nil {
+				if len(sets) > 1 {
+					return fmt.Errorf("set %d: %w", i+1, err)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (res *Result) load(s *store.Store, set *Set) error {
+	for _, g := range set.Genres {
+		if _, err := res.genre(s, g.Name, true); err != nil {
+			return err
+		}
+	}
+
+	for i, a := range set.Authors {
+		author, err := a.model()
+		if err != nil {
+			return fmt.Errorf("authors[%d]: %w", i, err)
+		}
+		if a.Ref != "" && res.Authors[a.Ref] != nil {
+			return fmt.Errorf("authors[%d]: ref %q is used twice", i, a.Ref)
+		}
+		if err := s.Authors.Add(author); err != nil {
+			return fmt.Errorf("authors[%d]: %w", i, err)
+		}
+		if a.Ref != "" {
+			res.Authors[a.Ref] = author
+		}
+	}
+
+	for i, b := range set.Books {
+		book, err := res.book(s, b)
+		if err != nil {
+			return fmt.Errorf("books[%d]: %w", i, err)
+		}
+		if err := s.Books.Add(book); err != nil {
+			return fmt.Errorf("books[%d]: %w", i, err)
+		}
+		res.Books = append(res.Books, book)
+	}
+	return nil
+}
+
+// genre returns the genre named name, from the database if it is there.
+// A missing genre is added when add is set and an error otherwise.
+func (res *Result) genre(s *store.Store, name string, add bool) (*models.Genre, error) {
+	name = strings.TrimSpace(name)
+	if g, ok := res.Genres[name]; ok {
+		return g, nil
+	}
+
+	g, err := s.Genres.GetByName(name)
+	if err == sql.ErrNoRows && add {
+		g = &models.Genre{Name: name}
+		err = s.Genres.Add(g)
+	} else if err == sql.ErrNoRows {
+		err = fmt.Errorf("unknown genre %q", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Genres[name] = g
+	return g, nil
+}
+
+// author returns the author with the ref, or else with the slug, ref.
+func (res *Result) author(s *store.Store, ref string) (*models.Author, error) {
+	if a, ok := res.Authors[ref]; ok {
+		return a, nil
+	}
+	a, err := s.Authors.GetBySlug(ref)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown author %q", ref)
+	}
+	return a, err
+}
+
+func (res *Result) book(s *store.Store, b Book) (*models.Book, error) {
+	release, err := parseDate(b.Release)
+	if err != nil {
+		return nil, fmt.Errorf("release: %w", err)
+	}
+	author, err := res.author(s, b.Author)
+	if err != nil {
+		return nil, err
+	}
+	genre, err := res.genre(s, b.Genre, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Book{
+		Name:      b.Name,
+		ISBN:      b.ISBN,
+		Release:   release,
+		Coast:     b.Coast,
+		Pages:     b.Pages,
+		PosterURL: b.PosterURL,
+		AuthorId:  author.Id,
+		GenreId:   genre.Id,
+	}, nil
+}
+
+func (a Author) model() (*models.Author, error) {
+	birthDay, err := parseDate(a.BirthDay)
+	if err != nil {
+		return nil, fmt.Errorf("birth_day: %w", err)
+	}
+	author := &models.Author{
+		LastName:    a.LastName,
+		FirstName:   a.FirstName,
+		MiddleName:  a.MiddleName,
+		PenName:     a.PenName,
+		BirthDay:    birthDay,
+		Bio:         a.Bio,
+		Nationality: a.Nationality,
+		PortraitURL: a.PortraitURL,
+		Website:     a.Website,
+		SocialLinks: a.SocialLinks,
+		Slug:        a.Slug,
+	}
+	if a.DeathDay != "" {
+		deathDay, err := parseDate(a.DeathDay)
+		if err != nil {
+			return nil, fmt.Errorf("death_day: %w", err)
+		}
+		author.DeathDay = &deathDay
+	}
+	return author, nil
+}
+
+// parseDate parses a date of a fixture file; an empty one is the zero time.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date such as 2006-01-02", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,174 @@
+package fixture
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	yamlSet := `
+genres:
+  - name: Poetry
+authors:
+  - ref: shevchenko
+    last_name: Shevchenko
+    first_name: Taras
+    birth_day: 1814-03-09
+books:
+  - name: Kobzar
+    release: 1840-04-18
+    coast: 500
+    pages: 114
+    author: shevchenko
+    genre: Poetry
+`
+	jsonSet := `{
+	"genres": [{"name": "Poetry"}],
+	"authors": [{"ref": "shevchenko", "last_name": "Shevchenko", "first_name": "Taras", "birth_day": "1814-03-09"}],
+	"books": [{"name": "Kobzar", "release": "1840-04-18", "coast": 500, "pages": 114, "author": "shevchenko", "genre": "Poetry"}]
+}`
+
+	fromYAML, err := Parse(strings.NewReader(yamlSet), YAML)
+	assert.NoError(t, err)
+	fromJSON, err := Parse(strings.NewReader(jsonSet), JSON)
+	assert.NoError(t, err)
+	assert.Equal(t, fromJSON, fromYAML)
+	assert.Equal(t, "1814-03-09", fromYAML.Authors[0].BirthDay)
+
+	empty, err := Parse(strings.NewReader(""), YAML)
+	assert.NoError(t, err)
+	assert.Equal(t, &Set{}, empty)
+
+	testCases := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{name: "unknown YAML key", input: "books:\n  - title: Kobzar\n", format: YAML},
+		{name: "unknown JSON key", input: `{"books": [{"title": "Kobzar"}]}`, format: JSON},
+		{name: "wrong type", input: `{"books": [{"pages": "many"}]}`, format: JSON},
+		{name: "unknown format", input: "", format: "toml"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.input), tc.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	set, err := ReadFile(Path("classics.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(set.Authors))
+	assert.Equal(t, 9, len(set.Books))
+
+	path := filepath.Join(t.TempDir(), "set.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+	_, err = ReadFile(path)
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := store.NewStoreFor(conn, db.TestDriver())
+
+	res := LoadFiles(t, s, Path("classics.yaml"))
+	assert.Equal(t, 9, len(res.Books))
+
+	dune := res.Books[8]
+	book, err := s.Books.GetById(int(dune.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", book.Name)
+	assert.Equal(t, "Herbert Frank", book.AuthorName)
+	assert.Equal(t, "Science fiction", book.GenreName)
+	assert.Equal(t, "1965-08-01", book.Release.Format(DateLayout))
+
+	orwell, err := s.Authors.Get(int(res.Authors["orwell"].Id))
+	assert.NoError(t, err)
+	assert.Equal(t, "George Orwell", orwell.DisplayName(models.NameLastFirst))
+	assert.Equal(t, "1950-01-21", orwell.DeathDay.Format(DateLayout))
+
+	// Genres and authors already in the database can be referred to.
+	more := &Set{Books: []Book{
+		{Name: "The Two Towers", Release: "1954-11-11", Coast: 1299, Pages: 352, Author: "john-ronald-tolkien", Genre: "Fantasy"},
+		{Name: "Test sequel", Release: "2001-01-01", Coast: 100, Pages: 10, Author: "frank-herbert", Genre: "test_genre"},
+	}}
+	genres, err := s.Genres.GetAll()
+	assert.NoError(t, err)
+	res, err = Load(s, &Set{Genres: []Genre{{Name: "Fantasy"}}}, more)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(res.Books))
+	after, err := s.Genres.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, len(genres), len(after))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := store.NewStoreFor(conn, db.TestDriver())
+
+	author := Author{Ref: "a", LastName: "Last", FirstName: "First", BirthDay: "1950-01-01"}
+	book := Book{Name: "Book", Release: "2000-01-01", Coast: 100, Pages: 100, Author: "a", Genre: "test_genre"}
+
+	testCases := []struct {
+		name  string
+		set   *Set
+		error string
+	}{
+		{name: "unknown author", set: &Set{Books: []Book{book}}, error: `books[0]: unknown author "a"`},
+		{name: "unknown genre", set: &Set{Authors: []Author{author}, Books: []Book{func() Book { b := book; b.Genre = "Jazz"; return b }()}},
+			error: `books[0]: unknown genre "Jazz"`},
+		{name: "bad date", set: &Set{Authors: []Author{{Ref: "a", LastName: "L", FirstName: "F", BirthDay: "03.12.1968"}}},
+			error: `authors[0]: birth_day: "03.12.1968" is not a date such as 2006-01-02`},
+		{name: "missing birth date", set: &Set{Authors: []Author{{Ref: "a", LastName: "L", FirstName: "F"}}},
+			error: "authors[0]: birth_day: Birth date is require field"},
+		{name: "duplicate ref", set: &Set{Authors: []Author{author, author}}, error: `authors[1]: ref "a" is used twice`},
+		{name: "invalid book", set: &Set{Authors: []Author{author}, Books: []Book{func() Book { b := book; b.Pages = 0; return b }()}},
+			error: "books[0]: pages: Pages is require field"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(s, tc.set)
+			assert.EqualError(t, err, tc.error)
+		})
+	}
+
+	// A failing set rolls back the sets before it.
+	_, err := Load(s, &Set{Genres: []Genre{{Name: "Jazz"}}}, &Set{Books: []Book{book}})
+	assert.EqualError(t, err, `set 2: books[0]: unknown author "a"`)
+	_, err = s.Genres.GetByName("Jazz")
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	set := Generate(GenerateOptions{Books: 500, Seed: 7})
+	assert.Equal(t, set, Generate(GenerateOptions{Books: 500, Seed: 7}))
+	assert.NotEqual(t, set, Generate(GenerateOptions{Books: 500, Seed: 8}))
+	assert.Equal(t, 51, len(set.Authors))
+
+	isbns := map[string]bool{}
+	for _, b := range set.Books {
+		assert.True(t, models.IsValidISBN(b.ISBN), b.ISBN)
+		isbns[b.ISBN] = true
+	}
+	assert.Equal(t, 500, len(isbns))
+
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := store.NewStoreFor(conn, db.TestDriver())
+
+	res := LoadGenerated(t, s, GenerateOptions{Books: 500, Seed: 7})
+	assert.Equal(t, 500, len(res.Books))
+	count, err := s.Books.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 516, count)
+}
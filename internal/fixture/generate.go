@@ -0,0 +1,145 @@
+package fixture
+
+import (
+	"bookland/internal/models"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// GenerateOptions sizes a generated catalogue. Zero values take defaults.
+type GenerateOptions struct {
+	Books int
+	// Authors defaults to one author per ten books.
+	Authors int
+	// Seed makes the catalogue reproducible: the same options generate the
+	// same set.
+	Seed int64
+}
+
+var (
+	genreNames = []string{
+		"Fantasy", "Science fiction", "Mystery", "Thriller", "Romance", "Horror", "Historical fiction",
+		"Literary fiction", "Poetry", "Drama", "Biography", "History", "Philosophy", "Travel",
+		"Children's", "Young adult", "Humour", "Crime", "Adventure", "Essays",
+	}
+	firstNames = []string{
+		"Anna", "Boris", "Clara", "David", "Elena", "Frank", "Grace", "Henry", "Irene", "James",
+		"Katherine", "Leo", "Maria", "Nikolai", "Olga", "Peter", "Rosa", "Samuel", "Tatiana", "Victor",
+		"Wanda", "Yuri", "Zoe", "Arthur", "Beatrice", "Charles", "Dorothy", "Edgar", "Fyodor", "Harriet",
+	}
+	lastNames = []string{
+		"Adams", "Brontë", "Carver", "Dickens", "Eliot", "Faulkner", "Gogol", "Hardy", "Ibsen", "Joyce",
+		"Kafka", "Lessing", "Mann", "Nabokov", "Orwell", "Pushkin", "Rilke", "Steinbeck", "Tolstoy", "Updike",
+		"Verne", "Wilde", "Yeats", "Zola", "Austen", "Bulgakov", "Chekhov", "Dumas", "Hugo", "Woolf",
+	}
+	adjectives = []string{
+		"Silent", "Last", "Hidden", "Burning", "Broken", "Golden", "Distant", "Forgotten", "Crimson", "Endless",
+		"Winter", "Secret", "Lonely", "Northern", "Shattered", "Quiet", "Wandering", "Bitter", "Bright", "Lost",
+	}
+	nouns = []string{
+		"River", "Kingdom", "Garden", "Letter", "Station", "Mountain", "Orchard", "Harbour", "Empire", "Mirror",
+		"Road", "Island", "House", "Voyage", "Bridge", "Forest", "Sea", "Tower", "Summer", "Promise",
+	}
+	countries = []string{"GB", "US", "FR", "DE", "RU", "UA", "IT", "ES", "IE", "NO"}
+)
+
+// Generate returns a synthetic catalogue of valid, unique books. Author
+// productivity and genre popularity are skewed the way real catalogues are:
+// a few authors write many books and most write a handful.
+func Generate(o GenerateOptions) *Set {
+	if o.Books <= 0 {
+		o.Books = 1000
+	}
+	if o.Authors <= 0 {
+		o.Authors = o.Books/10 + 1
+	}
+	r := rand.New(rand.NewSource(o.Seed))
+
+	set := &Set{
+		Genres:  make([]Genre, len(genreNames)),
+		Authors: make([]Author, o.Authors),
+		Books:   make([]Book, o.Books),
+	}
+	for i, name := range genreNames {
+		set.Genres[i] = Genre{Name: name}
+	}
+
+	for i := range set.Authors {
+		born := date(r, 1800, 1990)
+		a := Author{
+			Ref:         fmt.Sprintf("author-%d", i+1),
+			LastName:    pick(r, lastNames),
+			FirstName:   pick(r, firstNames),
+			BirthDay:    born.Format(DateLayout),
+			Nationality: pick(r, countries),
+		}
+		// Names repeat, so the slug is numbered up front rather than by the
+		// store probing for a free one.
+		a.Slug = fmt.Sprintf("%s-%d", models.Slugify(a.FirstName+" "+a.LastName), i+1)
+		if died := born.AddDate(40+r.Intn(50), r.Intn(12), r.Intn(28)); died.Year() < 2020 {
+			a.DeathDay = died.Format(DateLayout)
+		}
+		set.Authors[i] = a
+	}
+
+	authors := rand.NewZipf(r, 1.1, 2, uint64(o.Authors-1))
+	genres := rand.NewZipf(r, 1.3, 1, uint64(len(genreNames)-1))
+	for i := range set.Books {
+		set.Books[i] = Book{
+			Name:    title(r, i),
+			ISBN:    isbn13(i),
+			Release: date(r, 1900, 2020).Format(DateLayout),
+			Coast:   uint(199 + r.Intn(4800)),
+			Pages:   uint(60 + r.Intn(1100)),
+			Author:  set.Authors[authors.Uint64()].Ref,
+			Genre:   genreNames[genres.Uint64()],
+		}
+	}
+	return set
+}
+
+func pick(r *rand.Rand, words []string) string {
+	return words[r.Intn(len(words))]
+}
+
+// date returns a random date in the years from through to.
+func date(r *rand.Rand, from, to int) time.Time {
+	start := time.Date(from, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := time.Date(to+1, 1, 1, 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24
+	return start.AddDate(0, 0, r.Intn(int(days)))
+}
+
+// title makes up the title of book n. Titles repeat in large catalogues, as
+// they do in real ones, so the number of the volume is added to some.
+func title(r *rand.Rand, n int) string {
+	var b strings.Builder
+	switch r.Intn(3) {
+	case 0:
+		b.WriteString("The " + pick(r, adjectives) + " " + pick(r, nouns))
+	case 1:
+		b.WriteString(pick(r, nouns) + " of the " + pick(r, adjectives) + " " + pick(r, nouns))
+	default:
+		b.WriteString(pick(r, adjectives) + " " + pick(r, nouns))
+	}
+	if n%7 == 0 {
+		fmt.Fprintf(&b, ", Volume %d", n%5+1)
+	}
+	return b.String()
+}
+
+// isbn13 returns the n-th ISBN-13 of the 979-8 range with a valid check
+// digit, so generated books never share an ISBN.
+func isbn13(n int) string {
+	digits := fmt.Sprintf("9798%08d", n%100000000)
+	sum := 0
+	for i, d := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(d-'0') * weight
+	}
+	return fmt.Sprintf("%s%d", digits, (10-sum%10)%10)
+}
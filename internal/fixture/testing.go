@@ -0,0 +1,71 @@
+package fixture
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/store"
+	"database/sql"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Path returns the path of the set named name in the sets directory next to
+// this package's source, e.g. Path("classics.yaml"), so tests in any package
+// can load it regardless of their working directory.
+func Path(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "sets", name)
+}
+
+// LoadFiles reads the sets in the files at paths and loads them into s,
+// failing the test on any error.
+func LoadFiles(t testing.TB, s *store.Store, paths ...string) *Result {
+	t.Helper()
+
+	sets := make([]*Set, len(paths))
+	for i, path := range paths {
+		set, err := ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sets[i] = set
+	}
+
+	res, err := Load(s, sets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// LoadGenerated loads a catalogue generated with o into s, failing the test
+// on any error.
+func LoadGenerated(t testing.TB, s *store.Store, o GenerateOptions) *Result {
+	t.Helper()
+
+	res, err := Load(s, Generate(o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// NewTestDB returns a database of db.TestDriver for the test alone, holding
+// the testdata.yaml set the tests of every package share.
+func NewTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return loadTestData(t, db.NewTestDB(t), db.TestDriver())
+}
+
+// NewTestSQLiteDB is NewTestDB for tests that need SQLite whatever
+// db.TestDriver is.
+func NewTestSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return loadTestData(t, db.NewTestSQLiteDB(t), db.DriverSQLite)
+}
+
+func loadTestData(t *testing.T, conn *sql.DB, driver string) *sql.DB {
+	t.Helper()
+	LoadFiles(t, store.NewStoreFor(conn, driver), Path("testdata.yaml"))
+	return conn
+}
@@ -0,0 +1,277 @@
+// Package importer loads books, authors and genres from CSV files.
+package importer
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout expected in the release and author_birthday columns.
+const DateLayout = "2006-01-02"
+
+// Columns that must be present in the CSV header. The header may list them
+// in any order; isbn is optional, and author_birthday is only needed for rows
+// whose author CreateMissing creates.
+var requiredColumns = []string{
+	"name", "release", "coast", "pages", "poster", "author_last_name", "author_first_name", "genre",
+}
+
+var errDryRun = errors.New("dry run")
+
+type Options struct {
+	// CreateMissing creates authors and genres that are not found by name.
+	// Otherwise such rows are reported as errors.
+	CreateMissing bool
+	// DryRun validates and imports every row, then rolls the transaction back.
+	DryRun bool
+}
+
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+type Report struct {
+	Rows int
+	// Imported counts the rows that passed; they are only written when
+	// Errors is empty and the import is not a dry run.
+	Imported       int
+	CreatedAuthors int
+	CreatedGenres  int
+	Errors         []*RowError
+}
+
+// Import reads books from r and adds them to the store in a single transaction.
+// If any row fails, nothing is written and the returned report lists every
+// failing row with its line number.
+func Import(s *store.Store, r io.Reader, opts Options) (*Report, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	report := &Report{}
+	err = s.Tx(func(tx *store.Store) error {
+		imp := &importer{
+			store:   tx,
+			opts:    opts,
+			report:  report,
+			columns: columns,
+			authors: map[[2]string]int64{},
+			genres:  map[string]int64{},
+		}
+
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					report.Errors = append(report.Errors, &RowError{Line: parseErr.Line, Err: parseErr.Err})
+					continue
+				}
+				return err
+			}
+
+			line, _ := reader.FieldPos(0)
+			report.Rows++
+			if err := imp.importRow(record); err != nil {
+				report.Errors = append(report.Errors, &RowError{Line: line, Err: err})
+				continue
+			}
+			report.Imported++
+		}
+
+		if len(report.Errors) > 0 {
+			return fmt.Errorf("%d of %d rows failed", len(report.Errors), report.Rows)
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+
+	if err != nil && err != errDryRun {
+		return report, err
+	}
+
+	return report, nil
+}
+
+type importer struct {
+	store   *store.Store
+	opts    Options
+	report  *Report
+	columns map[string]int
+	authors map[[2]string]int64
+	genres  map[string]int64
+	// The authors and genres created by the current row.
+	rowAuthors [][2]string
+	rowGenres  []string
+}
+
+func (imp *importer) field(record []string, name string) string {
+	i, ok := imp.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// importRow adds the book of record within a savepoint, so a failing row
+// leaves nothing behind and, on PostgreSQL, does not abort the transaction
+// for the rows after it.
+func (imp *importer) importRow(record []string) error {
+	createdAuthors, createdGenres := imp.report.CreatedAuthors, imp.report.CreatedGenres
+	imp.rowAuthors, imp.rowGenres = nil, nil
+
+	err := imp.store.Savepoint(func(*store.Store) error {
+		return imp.addBook(record)
+	})
+	if err != nil {
+		// The authors and genres the row created were rolled back with it.
+		for _, key := range imp.rowAuthors {
+			delete(imp.authors, key)
+		}
+		for _, name := range imp.rowGenres {
+			delete(imp.genres, name)
+		}
+		imp.report.CreatedAuthors, imp.report.CreatedGenres = createdAuthors, createdGenres
+	}
+	return err
+}
+
+func (imp *importer) addBook(record []string) error {
+	book := &models.Book{
+		Name:      imp.field(record, "name"),
+		ISBN:      imp.field(record, "isbn"),
+		PosterURL: imp.field(record, "poster"),
+	}
+
+	var err error
+	if book.Release, err = time.Parse(DateLayout, imp.field(record, "release")); err != nil {
+		return fmt.Errorf("release: %w", err)
+	}
+
+	coast, err := strconv.ParseUint(imp.field(record, "coast"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("coast: %w", err)
+	}
+	book.Coast = uint(coast)
+
+	pages, err := strconv.ParseUint(imp.field(record, "pages"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("pages: %w", err)
+	}
+	book.Pages = uint(pages)
+
+	if book.AuthorId, err = imp.resolveAuthor(record); err != nil {
+		return err
+	}
+
+	if book.GenreId, err = imp.resolveGenre(imp.field(record, "genre")); err != nil {
+		return err
+	}
+
+	if err := book.Validate(); err != nil {
+		return err
+	}
+
+	return imp.store.Books.Add(book)
+}
+
+func (imp *importer) resolveAuthor(record []string) (int64, error) {
+	lastName := imp.field(record, "author_last_name")
+	firstName := imp.field(record, "author_first_name")
+	if lastName == "" || firstName == "" {
+		return 0, errors.New("author_last_name and author_first_name are require fields")
+	}
+
+	key := [2]string{lastName, firstName}
+	if id, ok := imp.authors[key]; ok {
+		return id, nil
+	}
+
+	author, err := imp.store.Authors.GetByName(lastName, firstName)
+	switch {
+	case err == nil:
+	case err != sql.ErrNoRows:
+		return 0, err
+	case !imp.opts.CreateMissing:
+		return 0, fmt.Errorf("author %q not found", lastName+" "+firstName)
+	default:
+		author = &models.Author{LastName: lastName, FirstName: firstName}
+		if birthday := imp.field(record, "author_birthday"); birthday != "" {
+			if author.BirthDay, err = time.Parse(DateLayout, birthday); err != nil {
+				return 0, fmt.Errorf("author_birthday: %w", err)
+			}
+		}
+		if err := imp.store.Authors.Add(author); err != nil {
+			return 0, err
+		}
+		imp.rowAuthors = append(imp.rowAuthors, key)
+		imp.report.CreatedAuthors++
+	}
+
+	imp.authors[key] = author.Id
+	return author.Id, nil
+}
+
+func (imp *importer) resolveGenre(name string) (int64, error) {
+	if name == "" {
+		return 0, errors.New("genre is require field")
+	}
+
+	if id, ok := imp.genres[name]; ok {
+		return id, nil
+	}
+
+	genre, err := imp.store.Genres.GetByName(name)
+	switch {
+	case err == nil:
+	case err != sql.ErrNoRows:
+		return 0, err
+	case !imp.opts.CreateMissing:
+		return 0, fmt.Errorf("genre %q not found", name)
+	default:
+		genre = &models.Genre{Name: name}
+		if err := imp.store.Genres.Add(genre); err != nil {
+			return 0, err
+		}
+		imp.rowGenres = append(imp.rowGenres, name)
+		imp.report.CreatedGenres++
+	}
+
+	imp.genres[name] = genre.Id
+	return genre.Id, nil
+}
@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"bookland/internal/fixture"
+	"bookland/internal/store"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const header = "name,release,coast,pages,poster,author_last_name,author_first_name,genre\n"
+
+const createHeader = "name,release,coast,pages,poster,author_last_name,author_first_name,author_birthday,genre\n"
+
+func TestImport(t *testing.T) {
+	testCases := []struct {
+		name           string
+		csv            string
+		opts           Options
+		valid          bool
+		imported       int
+		createdAuthors int
+		createdGenres  int
+		errorLines     []int
+		booksAfter     int
+	}{
+		{
+			name: "existing author and genre",
+			csv: header +
+				"Imported 1,2015-05-01,200,100,img.png,Potter,Harry,test_genre\n" +
+				"Imported 2,2016-05-01,300,120,img.png,Laurence,Freddy,test_genre 2\n",
+			valid:      true,
+			imported:   2,
+			booksAfter: 18,
+		},
+		{
+			name: "missing author without create",
+			csv: header +
+				"Imported 1,2015-05-01,200,100,img.png,Potter,Harry,test_genre\n" +
+				"Imported 2,2016-05-01,300,120,img.png,Pratchett,Terry,test_genre\n",
+			valid:      false,
+			imported:   1,
+			errorLines: []int{3},
+			booksAfter: 16,
+		},
+		{
+			name: "missing author and genre with create",
+			csv: createHeader +
+				"Imported 1,2015-05-01,200,100,img.png,Pratchett,Terry,1948-04-28,Fantasy\n" +
+				"Imported 2,2016-05-01,300,120,img.png,Pratchett,Terry,1948-04-28,Fantasy\n",
+			opts:           Options{CreateMissing: true},
+			valid:          true,
+			imported:       2,
+			createdAuthors: 1,
+			createdGenres:  1,
+			booksAfter:     18,
+		},
+		{
+			name: "missing author birthday with create",
+			csv: header +
+				"Imported 1,2015-05-01,200,100,img.png,Pratchett,Terry,Fantasy\n",
+			opts:       Options{CreateMissing: true},
+			valid:      false,
+			errorLines: []int{2},
+			booksAfter: 16,
+		},
+		{
+			name: "failing row rolls back the author it created",
+			csv: createHeader +
+				"Imported 1,2015-05-01,0,100,img.png,Pratchett,Terry,1948-04-28,test_genre\n" +
+				"Imported 2,2016-05-01,300,120,img.png,Pratchett,Terry,1948-04-28,test_genre\n",
+			opts:           Options{CreateMissing: true, DryRun: true},
+			valid:          false,
+			imported:       1,
+			createdAuthors: 1,
+			errorLines:     []int{2},
+			booksAfter:     16,
+		},
+		{
+			name: "invalid rows",
+			csv: header +
+				"Imported 1,2015-05-01,0,100,img.png,Potter,Harry,test_genre\n" +
+				"Imported 2,not a date,300,120,img.png,Potter,Harry,test_genre\n" +
+				"Imported 3,2016-05-01,300,120,img.png,Potter,Harry,test_genre\n",
+			valid:      false,
+			imported:   1,
+			errorLines: []int{2, 3},
+			booksAfter: 16,
+		},
+		{
+			name: "isbn column",
+			csv: "name,isbn,release,coast,pages,poster,author_last_name,author_first_name,genre\n" +
+				"Imported 1,0-306-40615-2,2015-05-01,200,100,img.png,Potter,Harry,test_genre\n" +
+				"Imported 2,978-0-306-40615-8,2016-05-01,300,120,img.png,Potter,Harry,test_genre\n",
+			valid:      false,
+			imported:   1,
+			errorLines: []int{3},
+			booksAfter: 16,
+		},
+		{
+			name: "dry run",
+			csv: createHeader +
+				"Imported 1,2015-05-01,200,100,img.png,Pratchett,Terry,1948-04-28,Fantasy\n",
+			opts:           Options{CreateMissing: true, DryRun: true},
+			valid:          true,
+			imported:       1,
+			createdAuthors: 1,
+			createdGenres:  1,
+			booksAfter:     16,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := fixture.NewTestSQLiteDB(t)
+			defer conn.Close()
+			s := store.NewStore(conn)
+
+			report, err := Import(s, strings.NewReader(tc.csv), tc.opts)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Empty(t, report.Errors)
+			} else {
+				assert.Error(t, err)
+				var lines []int
+				for _, rowErr := range report.Errors {
+					lines = append(lines, rowErr.Line)
+				}
+				assert.Equal(t, tc.errorLines, lines)
+			}
+			assert.Equal(t, tc.imported, report.Imported)
+			assert.Equal(t, tc.createdAuthors, report.CreatedAuthors)
+			assert.Equal(t, tc.createdGenres, report.CreatedGenres)
+
+			count, err := s.Books.Count()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.booksAfter, count)
+		})
+	}
+}
+
+func TestImport_MissingColumn(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+
+	report, err := Import(store.NewStore(conn), strings.NewReader("name,release\n"), Options{})
+	assert.Error(t, err)
+	assert.Nil(t, report)
+}
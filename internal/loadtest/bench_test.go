@@ -0,0 +1,79 @@
+package loadtest
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/store"
+	"database/sql"
+	"flag"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// The benchmarks run against a generated catalogue whose size is set with
+// -books, e.g. go test -run NONE -bench . ./internal/loadtest -args -books 100000.
+// Their output can be compared across changes with benchstat.
+var benchBooks = flag.Int("books", 10000, "books in the catalogue the benchmarks query")
+
+var bench struct {
+	once sync.Once
+	dir  string
+	conn *sql.DB
+	smp  *sample
+	err  error
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	if bench.conn != nil {
+		bench.conn.Close()
+	}
+	if bench.dir != "" {
+		os.RemoveAll(bench.dir)
+	}
+	os.Exit(code)
+}
+
+// benchCatalogue creates the catalogue the first time a benchmark needs it.
+func benchCatalogue(b *testing.B) (*store.Store, *sample) {
+	b.Helper()
+
+	bench.once.Do(func() {
+		if bench.dir, bench.err = ioutil.TempDir("", "bookland-bench-"); bench.err != nil {
+			return
+		}
+		c := db.DefaultConfig()
+		c.DSN = filepath.Join(bench.dir, "bench.db")
+		if bench.conn, bench.err = Create(c, fixture.GenerateOptions{Books: *benchBooks, Seed: 1}); bench.err != nil {
+			return
+		}
+		bench.smp, bench.err = newSample(store.NewStore(bench.conn))
+	})
+	if bench.err != nil {
+		b.Fatal(bench.err)
+	}
+	return store.NewStore(bench.conn), bench.smp
+}
+
+func BenchmarkRepository(b *testing.B) {
+	s, smp := benchCatalogue(b)
+
+	for _, op := range Operations {
+		op := op
+		b.Run(op.Name, func(b *testing.B) {
+			r := rand.New(rand.NewSource(1))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := op.run(s, r, smp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
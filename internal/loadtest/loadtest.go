@@ -0,0 +1,296 @@
+// Package loadtest measures the latency of the repository methods the API
+// depends on against a catalogue of any size, and compares the results with
+// an earlier run to reveal regressions.
+package loadtest
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/store"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation is one repository call with arguments drawn from a sample of the
+// catalogue, so that it queries rows that exist.
+type Operation struct {
+	Name string
+	run  func(s *store.Store, r *rand.Rand, smp *sample) error
+}
+
+// Operations lists every operation measured, in report order.
+var Operations = []Operation{
+	{"Books.GetPerPage", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.GetPerPage(pageSize, 1+r.Intn(smp.bookPages))
+		return err
+	}},
+	{"Books.GetById", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.GetById(pick(r, smp.bookIds))
+		return err
+	}},
+	{"Books.GetByAuthor", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.GetByAuthor(pick(r, smp.authorIds), pageSize, 1)
+		return err
+	}},
+	{"Books.GetByGenre", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.GetByGenre(pick(r, smp.genreIds), pageSize, 1+r.Intn(10))
+		return err
+	}},
+	{"Books.Search", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.Search(smp.terms[r.Intn(len(smp.terms))])
+		return err
+	}},
+	{"Books.Count", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Books.Count()
+		return err
+	}},
+	{"Authors.GetPerPage", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Authors.GetPerPage(pageSize, 1+r.Intn(smp.authorPages))
+		return err
+	}},
+	{"Authors.SearchByName", func(s *store.Store, r *rand.Rand, smp *sample) error {
+		_, err := s.Authors.SearchByName(smp.names[r.Intn(len(smp.names))])
+		return err
+	}},
+}
+
+// pageSize is the page size of the listing operations, as the API uses.
+const pageSize = 20
+
+// sample holds ids and words found in the catalogue.
+type sample struct {
+	books       int
+	authors     int
+	bookPages   int
+	authorPages int
+	bookIds     []int
+	authorIds   []int
+	genreIds    []int
+	terms       []string
+	names       []string
+}
+
+// sampledPages is how many pages of 100 books spread over the catalogue
+// newSample reads.
+const sampledPages = 20
+
+func newSample(s *store.Store) (*sample, error) {
+	books, err := s.Books.Count()
+	if err != nil {
+		return nil, err
+	}
+	if books == 0 {
+		return nil, fmt.Errorf("the catalogue has no books to query")
+	}
+	authors, err := s.Authors.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	smp := &sample{
+		books:       books,
+		authors:     authors,
+		bookPages:   (books + pageSize - 1) / pageSize,
+		authorPages: (authors+pageSize-1)/pageSize + 1,
+	}
+	seen := map[string]bool{}
+	add := func(list *[]string, value string) {
+		if len(value) >= 4 && !seen[value] {
+			seen[value] = true
+			*list = append(*list, value)
+		}
+	}
+	authorIds, genreIds := map[int]bool{}, map[int]bool{}
+
+	pages := (books + 99) / 100
+	for i := 0; i < sampledPages && i < pages; i++ {
+		list, err := s.Books.GetPerPage(100, 1+i*pages/sampledPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range list {
+			smp.bookIds = append(smp.bookIds, int(b.Id))
+			authorIds[int(b.AuthorId)] = true
+			genreIds[int(b.GenreId)] = true
+			for _, word := range strings.Fields(b.Name) {
+				add(&smp.terms, strings.Trim(word, ",.:;"))
+			}
+			if fields := strings.Fields(b.AuthorName); len(fields) > 0 {
+				add(&smp.names, fields[0])
+			}
+		}
+	}
+	if len(smp.terms) == 0 {
+		smp.terms = []string{"book"}
+	}
+	if len(smp.names) == 0 {
+		smp.names = []string{"a"}
+	}
+	smp.authorIds = sortedKeys(authorIds)
+	smp.genreIds = sortedKeys(genreIds)
+	sort.Strings(smp.terms)
+	sort.Strings(smp.names)
+	return smp, nil
+}
+
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func pick(r *rand.Rand, ids []int) int {
+	return ids[r.Intn(len(ids))]
+}
+
+type Options struct {
+	// Iterations is how often each operation is measured, 100 by default.
+	Iterations int
+	// Concurrency is how many goroutines call an operation at once, 1 by
+	// default.
+	Concurrency int
+	// Operations names the operations to measure, all when empty.
+	Operations []string
+	// Seed makes the arguments of the operations reproducible.
+	Seed int64
+}
+
+// Run measures the operations against s one after the other and reports
+// the latency distribution of each. Every operation is called once before
+// measuring, to warm caches up.
+func Run(s *store.Store, o Options) (*Report, error) {
+	if o.Iterations <= 0 {
+		o.Iterations = 100
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	ops, err := selectOperations(o.Operations)
+	if err != nil {
+		return nil, err
+	}
+
+	smp, err := newSample(s)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newReport(smp, o)
+	for _, op := range ops {
+		result, err := measure(s, smp, op, o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Name, err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+func selectOperations(names []string) ([]Operation, error) {
+	if len(names) == 0 {
+		return Operations, nil
+	}
+
+	var ops []Operation
+	for _, name := range names {
+		op, ok := findOperation(name)
+		if !ok {
+			known := make([]string, len(Operations))
+			for i, op := range Operations {
+				known[i] = op.Name
+			}
+			return nil, fmt.Errorf("unknown operation %q, want one of %s", name, strings.Join(known, ", "))
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func findOperation(name string) (Operation, bool) {
+	for _, op := range Operations {
+		if strings.EqualFold(op.Name, name) {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// measure calls op o.Iterations times from o.Concurrency goroutines, each
+// drawing arguments from a generator of its own.
+func measure(s *store.Store, smp *sample, op Operation, o Options) (Result, error) {
+	if err := op.run(s, rand.New(rand.NewSource(o.Seed)), smp); err != nil {
+		return Result{}, err
+	}
+
+	latencies := make([]time.Duration, o.Iterations)
+	next := make(chan int)
+	errs := make(chan error, o.Concurrency)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for w := 0; w < o.Concurrency; w++ {
+		wg.Add(1)
+		go func(r *rand.Rand) {
+			defer wg.Done()
+			for i := range next {
+				began := time.Now()
+				if err := op.run(s, r, smp); err != nil {
+					errs <- err
+					return
+				}
+				latencies[i] = time.Since(began)
+			}
+		}(rand.New(rand.NewSource(o.Seed + int64(w) + 1)))
+	}
+
+	var err error
+feed:
+	for i := 0; i < o.Iterations; i++ {
+		select {
+		case next <- i:
+		case err = <-errs:
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if err == nil && len(errs) > 0 {
+		err = <-errs
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(op.Name, latencies, elapsed), nil
+}
+
+// Create opens the SQLite database c describes, which should be new,
+// migrates it and loads a catalogue generated with o into it.
+func Create(c db.Config, o fixture.GenerateOptions) (*sql.DB, error) {
+	if c.Driver != db.DriverSQLite {
+		return nil, fmt.Errorf("catalogues can only be created in %s databases", db.DriverSQLite)
+	}
+
+	conn, err := db.Open(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Migrate(conn, c.Driver); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	if _, err := fixture.Load(store.NewStoreFor(conn, c.Driver).WithActor("loadtest"), fixture.Generate(o)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,133 @@
+package loadtest
+
+import (
+	"bookland/internal/fixture"
+	"bookland/internal/store"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	s := store.NewStore(conn)
+	fixture.LoadGenerated(t, s, fixture.GenerateOptions{Books: 300, Seed: 3})
+
+	report, err := Run(s, Options{Iterations: 20, Concurrency: 3, Seed: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 316, report.Books)
+	assert.Equal(t, 33, report.Authors)
+	assert.Equal(t, len(Operations), len(report.Results))
+	for i, res := range report.Results {
+		assert.Equal(t, Operations[i].Name, res.Operation)
+		assert.Equal(t, 20, res.Calls)
+		assert.True(t, res.Min > 0)
+		assert.True(t, res.Min <= res.P50 && res.P50 <= res.P90 && res.P90 <= res.P95 &&
+			res.P95 <= res.P99 && res.P99 <= res.Max, res.Operation)
+		assert.True(t, res.Throughput > 0)
+	}
+
+	var text bytes.Buffer
+	assert.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "Books.GetByAuthor")
+
+	report, err = Run(s, Options{Iterations: 5, Operations: []string{"books.search", "Books.Count"}})
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(report.Results)) {
+		assert.Equal(t, "Books.Search", report.Results[0].Operation)
+	}
+
+	_, err = Run(s, Options{Operations: []string{"Books.Purge"}})
+	assert.Error(t, err)
+}
+
+func TestRun_EmptyCatalogue(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	_, err := conn.Exec("DELETE FROM book_history; DELETE FROM book")
+	assert.NoError(t, err)
+
+	_, err = Run(store.NewStore(conn), Options{})
+	assert.Error(t, err)
+}
+
+func TestNewResult(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(100-i) * time.Millisecond
+	}
+
+	res := newResult("op", latencies, 2*time.Second)
+	assert.Equal(t, Result{
+		Operation:  "op",
+		Calls:      100,
+		Min:        time.Millisecond,
+		Mean:       50500 * time.Microsecond,
+		P50:        50 * time.Millisecond,
+		P90:        90 * time.Millisecond,
+		P95:        95 * time.Millisecond,
+		P99:        99 * time.Millisecond,
+		Max:        100 * time.Millisecond,
+		Throughput: 50,
+	}, res)
+	assert.Equal(t, 100*time.Millisecond, latencies[0], "the latencies are not reordered")
+}
+
+func TestCompare(t *testing.T) {
+	result := func(name string, p50, p95 time.Duration) Result {
+		return Result{Operation: name, P50: p50, P95: p95}
+	}
+	base := &Report{Books: 1000, Authors: 100, Concurrency: 1, Results: []Result{
+		result("Books.GetPerPage", time.Millisecond, 2*time.Millisecond),
+		result("Books.Search", 10*time.Millisecond, 20*time.Millisecond),
+		result("Books.Count", 10*time.Microsecond, 20*time.Microsecond),
+		result("Books.GetById", time.Millisecond, 2*time.Millisecond),
+	}}
+	current := &Report{Books: 1000, Authors: 100, Concurrency: 1, Results: []Result{
+		result("Books.GetPerPage", 1100*time.Microsecond, 2100*time.Microsecond),
+		result("Books.Search", 10*time.Millisecond, 30*time.Millisecond),
+		// Tripled, but by less than MinRegression.
+		result("Books.Count", 30*time.Microsecond, 60*time.Microsecond),
+		result("Authors.SearchByName", time.Millisecond, time.Millisecond),
+	}}
+
+	c := Compare(base, current, 0.2)
+	assert.Empty(t, c.Notes)
+	assert.Equal(t, 3, len(c.Changes))
+	assert.Equal(t, []string{"Books.Search"}, c.Regressions())
+
+	var text bytes.Buffer
+	assert.NoError(t, c.WriteText(&text))
+	assert.Contains(t, text.String(), "+50.0%")
+	assert.Contains(t, text.String(), "1 regressed: Books.Search")
+
+	current.Books = 2000
+	assert.Equal(t, 1, len(Compare(base, current, 0.2).Notes))
+}
+
+func TestReport_JSON(t *testing.T) {
+	report := &Report{
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Books:     10,
+		Results:   []Result{{Operation: "Books.Count", Calls: 3, P50: 1500 * time.Microsecond, Throughput: 12.5}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteJSON(&buf))
+	assert.Contains(t, buf.String(), `"p50_ns": 1500000`)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+	read, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, report, read)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(strings.Repeat("{", 3)), 0644))
+	_, err = ReadFile(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,239 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the outcome of Run. Written as JSON it is the baseline a later
+// run is compared with.
+type Report struct {
+	CreatedAt   time.Time `json:"created_at"`
+	GoVersion   string    `json:"go_version"`
+	Driver      string    `json:"driver,omitempty"`
+	Books       int       `json:"books"`
+	Authors     int       `json:"authors"`
+	Iterations  int       `json:"iterations"`
+	Concurrency int       `json:"concurrency"`
+	Seed        int64     `json:"seed"`
+	Results     []Result  `json:"results"`
+}
+
+// Result is the latency distribution of one operation.
+type Result struct {
+	Operation  string        `json:"operation"`
+	Calls      int           `json:"calls"`
+	Min        time.Duration `json:"min_ns"`
+	Mean       time.Duration `json:"mean_ns"`
+	P50        time.Duration `json:"p50_ns"`
+	P90        time.Duration `json:"p90_ns"`
+	P95        time.Duration `json:"p95_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+	Throughput float64       `json:"ops_per_sec"`
+}
+
+func newReport(smp *sample, o Options) *Report {
+	return &Report{
+		CreatedAt:   time.Now().UTC(),
+		GoVersion:   runtime.Version(),
+		Books:       smp.books,
+		Authors:     smp.authors,
+		Iterations:  o.Iterations,
+		Concurrency: o.Concurrency,
+		Seed:        o.Seed,
+	}
+}
+
+func newResult(name string, latencies []time.Duration, elapsed time.Duration) Result {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return Result{
+		Operation:  name,
+		Calls:      len(sorted),
+		Min:        sorted[0],
+		Mean:       total / time.Duration(len(sorted)),
+		P50:        percentile(sorted, 50),
+		P90:        percentile(sorted, 90),
+		P95:        percentile(sorted, 95),
+		P99:        percentile(sorted, 99),
+		Max:        sorted[len(sorted)-1],
+		Throughput: float64(len(sorted)) / elapsed.Seconds(),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Result returns the result of the named operation.
+func (r *Report) Result(operation string) (Result, bool) {
+	for _, res := range r.Results {
+		if res.Operation == operation {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadFile reads a report WriteJSON wrote.
+func ReadFile(path string) (*Report, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// WriteText writes the report as a table, latencies in milliseconds.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%d books, %d authors, %d calls per operation, concurrency %d\n\n",
+		r.Books, r.Authors, r.Iterations, r.Concurrency)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tMIN\tMEAN\tP50\tP90\tP95\tP99\tMAX\tOPS/S\t")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.1f\t\n", res.Operation,
+			ms(res.Min), ms(res.Mean), ms(res.P50), ms(res.P90), ms(res.P95), ms(res.P99), ms(res.Max), res.Throughput)
+	}
+	return tw.Flush()
+}
+
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
+}
+
+// MinRegression is the smallest slowdown Compare reports as a regression,
+// so noise in operations taking microseconds does not count.
+const MinRegression = 100 * time.Microsecond
+
+// Change compares one operation of two reports.
+type Change struct {
+	Operation string
+	Base      Result
+	Current   Result
+	// Regression is set when P50 or P95 grew by more than the threshold.
+	Regression bool
+}
+
+// Comparison is the outcome of Compare.
+type Comparison struct {
+	Changes []Change
+	// Notes name differences between the runs that make them less
+	// comparable, such as the catalogue size.
+	Notes []string
+}
+
+// Compare compares current with base. An operation regressed when its P50
+// or P95 latency grew by more than threshold, a fraction such as 0.2, and by
+// at least MinRegression. Operations missing from either report are skipped.
+func Compare(base, current *Report, threshold float64) *Comparison {
+	c := &Comparison{}
+	if base.Books != current.Books || base.Authors != current.Authors {
+		c.Notes = append(c.Notes, fmt.Sprintf("catalogues differ: %d books and %d authors before, %d and %d now",
+			base.Books, base.Authors, current.Books, current.Authors))
+	}
+	if base.Concurrency != current.Concurrency {
+		c.Notes = append(c.Notes, fmt.Sprintf("concurrency differs: %d before, %d now", base.Concurrency, current.Concurrency))
+	}
+	if base.Driver != current.Driver {
+		c.Notes = append(c.Notes, fmt.Sprintf("drivers differ: %s before, %s now", base.Driver, current.Driver))
+	}
+
+	for _, cur := range current.Results {
+		old, ok := base.Result(cur.Operation)
+		if !ok {
+			continue
+		}
+		c.Changes = append(c.Changes, Change{
+			Operation:  cur.Operation,
+			Base:       old,
+			Current:    cur,
+			Regression: regressed(old.P50, cur.P50, threshold) || regressed(old.P95, cur.P95, threshold),
+		})
+	}
+	return c
+}
+
+func regressed(before, after time.Duration, threshold float64) bool {
+	return after-before >= MinRegression && float64(after) > float64(before)*(1+threshold)
+}
+
+// Regressions returns the operations that regressed.
+func (c *Comparison) Regressions() []string {
+	var names []string
+	for _, ch := range c.Changes {
+		if ch.Regression {
+			names = append(names, ch.Operation)
+		}
+	}
+	return names
+}
+
+// WriteText writes the comparison as a table of P50 and P95 latencies in
+// milliseconds with their relative change.
+func (c *Comparison) WriteText(w io.Writer) error {
+	for _, note := range c.Notes {
+		fmt.Fprintf(w, "note: %s\n", note)
+	}
+	if len(c.Notes) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tP50 BEFORE\tP50 NOW\tDELTA\tP95 BEFORE\tP95 NOW\tDELTA\t\t")
+	for _, ch := range c.Changes {
+		mark := ""
+		if ch.Regression {
+			mark = "REGRESSION"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", ch.Operation,
+			ms(ch.Base.P50), ms(ch.Current.P50), delta(ch.Base.P50, ch.Current.P50),
+			ms(ch.Base.P95), ms(ch.Current.P95), delta(ch.Base.P95, ch.Current.P95), mark)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if regressions := c.Regressions(); len(regressions) > 0 {
+		_, err := fmt.Fprintf(w, "\n%d regressed: %s\n", len(regressions), strings.Join(regressions, ", "))
+		return err
+	}
+	return nil
+}
+
+func delta(before, after time.Duration) string {
+	if before == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", (float64(after)/float64(before)-1)*100)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "strings"
+
+// AuthorAlias is another name an author publishes or is known under.
+type AuthorAlias struct {
+	Id       int64  `json:"id"`
+	AuthorId int64  `json:"author_id"`
+	Name     string `json:"name"`
+}
+
+// Validate returns ValidationErrors listing the invalid fields, if any.
+func (a *AuthorAlias) Validate() error {
+	var errs ValidationErrors
+
+	if strings.TrimSpace(a.Name) == "" {
+		errs.add("name", CodeRequired, "Alias name is require field")
+	}
+	errs.checkLength("name", a.Name, MaxNameLength, "Alias name is too long")
+
+	if a.AuthorId <= 0 {
+		errs.add("author_id", CodeRequired, "author_id is require field")
+	}
+
+	return errs.err()
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// FieldChange is a field's JSON value before and after a write.
+// From is null for created rows and To is null for deleted ones.
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// AuditEntry records one write to a catalogue entity.
+type AuditEntry struct {
+	Id        int64                  `json:"id"`
+	Entity    string                 `json:"entity"`
+	EntityId  int64                  `json:"entity_id"`
+	Action    string                 `json:"action"`
+	Actor     string                 `json:"actor"`
+	CreatedAt time.Time              `json:"created_at"`
+	Diff      map[string]FieldChange `json:"diff"`
+}
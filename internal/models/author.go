@@ -1,11 +1,116 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
+// Author is a person who writes books. PenName, when set, is the name the
+// author publishes under and is displayed instead of the legal name.
+// Nationality is an ISO 3166-1 alpha-2 country code. Slug identifies the
+// author in URLs and is derived from the name when left empty.
+// MatchedAlias is set by SearchByName when only one of the author's aliases
+// matched.
 type Author struct {
-	Id        int64     `json:"id"`
-	LastName  string    `json:"last_name"`
-	FirstName string    `json:"first_name"`
-	BirthDay  time.Time `json:"birth_day"`
-	Bio       string    `json:"bio"`
+	Id           int64      `json:"id"`
+	LastName     string     `json:"last_name"`
+	FirstName    string     `json:"first_name"`
+	MiddleName   string     `json:"middle_name"`
+	PenName      string     `json:"pen_name"`
+	BirthDay     time.Time  `json:"birth_day"`
+	DeathDay     *time.Time `json:"death_day,omitempty"`
+	Bio          string     `json:"bio"`
+	Nationality  string     `json:"nationality"`
+	PortraitURL  string     `json:"portrait_url"`
+	Website      string     `json:"website"`
+	SocialLinks  []string   `json:"social_links,omitempty"`
+	Slug         string     `json:"slug"`
+	Version      int64      `json:"version"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	MatchedAlias string     `json:"matched_alias,omitempty"`
+}
+
+// Normalize trims surrounding whitespace from the text fields.
+func (a *Author) Normalize() {
+	a.LastName = strings.TrimSpace(a.LastName)
+	a.FirstName = strings.TrimSpace(a.FirstName)
+	a.MiddleName = strings.TrimSpace(a.MiddleName)
+	a.PenName = strings.TrimSpace(a.PenName)
+	a.Bio = strings.TrimSpace(a.Bio)
+	a.Nationality = strings.TrimSpace(a.Nationality)
+	a.PortraitURL = strings.TrimSpace(a.PortraitURL)
+	a.Website = strings.TrimSpace(a.Website)
+	a.Slug = strings.TrimSpace(a.Slug)
+	for i := range a.SocialLinks {
+		a.SocialLinks[i] = strings.TrimSpace(a.SocialLinks[i])
+	}
+}
+
+// Validate checks every field and returns ValidationErrors listing all that
+// are invalid.
+func (a *Author) Validate() error {
+	var errs ValidationErrors
+
+	if a.LastName == "" {
+		errs.add("last_name", CodeRequired, "Last name is require field")
+	}
+	errs.checkLength("last_name", a.LastName, MaxNameLength, "Last name is too long")
+
+	if a.FirstName == "" {
+		errs.add("first_name", CodeRequired, "First name is require field")
+	}
+	errs.checkLength("first_name", a.FirstName, MaxNameLength, "First name is too long")
+	errs.checkLength("middle_name", a.MiddleName, MaxNameLength, "Middle name is too long")
+	errs.checkLength("pen_name", a.PenName, MaxNameLength, "Pen name is too long")
+
+	if a.BirthDay.IsZero() {
+		errs.add("birth_day", CodeRequired, "Birth date is require field")
+	} else if !a.BirthDay.Before(time.Now()) {
+		errs.add("birth_day", CodeNotInPast, "Birth date must be in past")
+	}
+
+	if a.DeathDay != nil {
+		if !a.DeathDay.Before(time.Now()) {
+			errs.add("death_day", CodeNotInPast, "Death date must be in past")
+		} else if !a.BirthDay.IsZero() && !a.DeathDay.After(a.BirthDay) {
+			errs.add("death_day", CodeOutOfRange, "Death date must be after birth date")
+		}
+	}
+
+	errs.checkLength("bio", a.Bio, MaxBioLength, "Bio is too long")
+
+	if a.Nationality != "" && !isCountryCode(a.Nationality) {
+		errs.add("nationality", CodeInvalid, "Nationality must be an ISO 3166-1 alpha-2 code")
+	}
+
+	if a.PortraitURL != "" && !IsValidURL(a.PortraitURL) {
+		errs.add("portrait_url", CodeInvalid, "Portrait URL is invalid")
+	}
+
+	if a.Website != "" && !IsValidURL(a.Website) {
+		errs.add("website", CodeInvalid, "Website is invalid")
+	}
+
+	for i, link := range a.SocialLinks {
+		if !IsValidURL(link) {
+			errs.add(fmt.Sprintf("social_links[%d]", i), CodeInvalid, fmt.Sprintf("Social link %d is invalid", i+1))
+		}
+	}
+
+	if a.Slug != "" && !IsValidSlug(a.Slug) {
+		errs.add("slug", CodeInvalid, "Slug may only contain lower case letters, digits and single hyphens")
+	}
+
+	return errs.err()
+}
+
+// IsValid reports the first problem Validate finds.
+func (a *Author) IsValid() (bool, string) {
+	return isValid(a.Validate())
+}
+
+func isCountryCode(s string) bool {
+	return len(s) == 2 && s[0] >= 'A' && s[0] <= 'Z' && s[1] >= 'A' && s[1] <= 'Z'
 }
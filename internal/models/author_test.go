@@ -0,0 +1,97 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthor_Validate(t *testing.T) {
+	birthDay := time.Date(1892, 1, 3, 0, 0, 0, 0, time.UTC)
+	deathDay := time.Date(1973, 9, 2, 0, 0, 0, 0, time.UTC)
+	beforeBirth := time.Date(1890, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().AddDate(1, 0, 0)
+
+	valid := func() *Author {
+		return &Author{
+			LastName:    "Tolkien",
+			FirstName:   "John",
+			BirthDay:    birthDay,
+			DeathDay:    &deathDay,
+			Nationality: "GB",
+			PortraitURL: "https://example.com/tolkien.jpg",
+			Website:     "https://www.tolkienestate.com",
+			SocialLinks: []string{"https://twitter.com/tolkien"},
+			Slug:        "john-tolkien",
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		change func(a *Author)
+		field  string
+		code   string
+	}{
+		{name: "valid author", change: func(a *Author) {}},
+		{name: "missing birth date", change: func(a *Author) { a.BirthDay, a.DeathDay = time.Time{}, nil }, field: "birth_day", code: CodeRequired},
+		{name: "missing last name", change: func(a *Author) { a.LastName = "" }, field: "last_name", code: CodeRequired},
+		{name: "missing first name", change: func(a *Author) { a.FirstName = "" }, field: "first_name", code: CodeRequired},
+		{name: "long pen name", change: func(a *Author) { a.PenName = strings.Repeat("п", MaxNameLength+1) }, field: "pen_name", code: CodeTooLong},
+		{name: "birth in future", change: func(a *Author) { a.BirthDay, a.DeathDay = future, nil }, field: "birth_day", code: CodeNotInPast},
+		{name: "death before birth", change: func(a *Author) { a.DeathDay = &beforeBirth }, field: "death_day", code: CodeOutOfRange},
+		{name: "death in future", change: func(a *Author) { a.DeathDay = &future }, field: "death_day", code: CodeNotInPast},
+		{name: "long bio", change: func(a *Author) { a.Bio = strings.Repeat("b", MaxBioLength+1) }, field: "bio", code: CodeTooLong},
+		{name: "invalid nationality", change: func(a *Author) { a.Nationality = "gbr" }, field: "nationality", code: CodeInvalid},
+		{name: "relative portrait url", change: func(a *Author) { a.PortraitURL = "img.png" }, field: "portrait_url", code: CodeInvalid},
+		{name: "invalid website scheme", change: func(a *Author) { a.Website = "ftp://example.com" }, field: "website", code: CodeInvalid},
+		{name: "invalid social link", change: func(a *Author) { a.SocialLinks = append(a.SocialLinks, "example") }, field: "social_links[1]", code: CodeInvalid},
+		{name: "invalid slug", change: func(a *Author) { a.Slug = "John Tolkien" }, field: "slug", code: CodeInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := valid()
+			tc.change(a)
+			err := a.Validate()
+			if tc.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			assert.True(t, ok)
+			assert.Equal(t, 1, len(errs))
+			assert.Equal(t, tc.field, errs[0].Field)
+			assert.Equal(t, tc.code, errs[0].Code)
+
+			valid, message := a.IsValid()
+			assert.False(t, valid)
+			assert.Equal(t, errs[0].Message, message)
+		})
+	}
+}
+
+func TestAuthor_ValidateAllFields(t *testing.T) {
+	a := &Author{LastName: "  ", Website: "example"}
+	a.Normalize()
+
+	err := a.Validate()
+	assert.Equal(t, ValidationErrors{
+		{Field: "last_name", Code: CodeRequired, Message: "Last name is require field"},
+		{Field: "first_name", Code: CodeRequired, Message: "First name is require field"},
+		{Field: "birth_day", Code: CodeRequired, Message: "Birth date is require field"},
+		{Field: "website", Code: CodeInvalid, Message: "Website is invalid"},
+	}, err)
+	assert.Equal(t, "last_name: Last name is require field; first_name: First name is require field; "+
+		"birth_day: Birth date is require field; website: Website is invalid", err.Error())
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "john-ronald-reuel-tolkien", Slugify("  John Ronald Reuel  Tolkien "))
+	assert.Equal(t, "o-brien-2", Slugify("O'Brien (2)"))
+	assert.Equal(t, "тарас-шевченко", Slugify("Тарас Шевченко"))
+	assert.True(t, IsValidSlug("o-brien-2"))
+	assert.False(t, IsValidSlug("-o-brien"))
+	assert.False(t, IsValidSlug(""))
+}
@@ -1,44 +1,82 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
+// Book is a work. Its Release, Coast, Pages, PosterURL and ISBN describe the
+// primary edition shown in listings. Format, Editions and Series are only
+// filled in when a single book is loaded. DeletedAt is set for books in the trash.
+// AuthorAlias is set by Search when the book matched only through one of its
+// author's aliases.
 type Book struct {
-	Id         int64     `json:"id"`
-	Name       string    `json:"name"`
-	Release    time.Time `json:"release"`
-	Coast      uint      `json:"coast"`
-	Pages      uint      `json:"pages"`
-	PosterURL  string    `json:"poster_url"`
-	AuthorId   int64     `json:"author_id"`
-	AuthorName string    `json:"author_name"`
-	GenreId    int64     `json:"genre_id"`
-	GenreName  string    `json:"genre_name"`
+	Id          int64          `json:"id"`
+	Name        string         `json:"name"`
+	ISBN        string         `json:"isbn"`
+	Format      Format         `json:"format,omitempty"`
+	Release     time.Time      `json:"release"`
+	Coast       uint           `json:"coast"`
+	Pages       uint           `json:"pages"`
+	PosterURL   string         `json:"poster_url"`
+	AuthorId    int64          `json:"author_id"`
+	AuthorName  string         `json:"author_name"`
+	GenreId     int64          `json:"genre_id"`
+	GenreName   string         `json:"genre_name"`
+	Version     int64          `json:"version"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty"`
+	AuthorAlias string         `json:"author_alias,omitempty"`
+	Editions    []Edition      `json:"editions,omitempty"`
+	Series      []SeriesVolume `json:"series,omitempty"`
 }
 
-func (b *Book) IsValid() (bool, string) {
+// Validate returns ValidationErrors listing every invalid field of the book
+// and its editions, if any. Pages may be 0 when Format, the primary
+// edition's, has none.
+func (b *Book) Validate() error {
+	var errs ValidationErrors
+
 	if b.Name == "" {
-		return false, "Book name is require field"
+		errs.add("name", CodeRequired, "Book name is require field")
+	}
+
+	if b.Format != "" && !b.Format.IsValid() {
+		errs.add("format", CodeInvalid, "Format is invalid")
+	}
+
+	if b.ISBN != "" && !IsValidISBN(b.ISBN) {
+		errs.add("isbn", CodeInvalid, "ISBN is invalid")
 	}
 
 	if !b.Release.Before(time.Now()) {
-		return false, "Release date must be in past"
+		errs.add("release", CodeNotInPast, "Release date must be in past")
 	}
 
 	if b.Coast == 0 {
-		return false, "Coast is require field"
+		errs.add("coast", CodeRequired, "Coast is require field")
 	}
 
-	if b.Pages == 0 {
-		return false, "Pages is require field"
+	if b.Pages == 0 && b.Format.HasPages() {
+		errs.add("pages", CodeRequired, "Pages is require field")
 	}
 
 	if b.AuthorId <= 0 {
-		return false, "author_id is require field"
+		errs.add("author_id", CodeRequired, "author_id is require field")
 	}
 
 	if b.GenreId <= 0 {
-		return false, "genre_id is require field"
+		errs.add("genre_id", CodeRequired, "genre_id is require field")
 	}
 
-	return true, ""
+	for i := range b.Editions {
+		errs.nest(fmt.Sprintf("editions[%d]", i), fmt.Sprintf("Edition %d: ", i+1), b.Editions[i].Validate())
+	}
+
+	return errs.err()
+}
+
+// IsValid reports the first problem Validate finds.
+func (b *Book) IsValid() (bool, string) {
+	return isValid(b.Validate())
 }
@@ -44,6 +44,40 @@ func TestBook_IsValid(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "valid book isbn",
+			book: &Book{
+				Id:         0,
+				Name:       "Book",
+				ISBN:       "0-306-40615-2",
+				Release:    time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:      250,
+				Pages:      200,
+				PosterURL:  "",
+				AuthorId:   1,
+				AuthorName: "",
+				GenreId:    1,
+				GenreName:  "",
+			},
+			valid: true,
+		},
+		{
+			name: "invalid book isbn",
+			book: &Book{
+				Id:         0,
+				Name:       "Book",
+				ISBN:       "978-0-306-40615-8",
+				Release:    time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:      250,
+				Pages:      200,
+				PosterURL:  "",
+				AuthorId:   1,
+				AuthorName: "",
+				GenreId:    1,
+				GenreName:  "",
+			},
+			valid: false,
+		},
 		{
 			name: "invalid book release date",
 			book: &Book{
@@ -92,6 +126,31 @@ func TestBook_IsValid(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "valid ebook without pages",
+			book: &Book{
+				Name:     "Book",
+				Format:   FormatEbook,
+				Release:  time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:    250,
+				AuthorId: 1,
+				GenreId:  1,
+			},
+			valid: true,
+		},
+		{
+			name: "invalid book format",
+			book: &Book{
+				Name:     "Book",
+				Format:   "scroll",
+				Release:  time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:    250,
+				Pages:    200,
+				AuthorId: 1,
+				GenreId:  1,
+			},
+			valid: false,
+		},
 		{
 			name: "invalid book author",
 			book: &Book{
@@ -124,6 +183,23 @@ func TestBook_IsValid(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "invalid book edition",
+			book: &Book{
+				Id:         0,
+				Name:       "Book",
+				Release:    time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:      250,
+				Pages:      200,
+				PosterURL:  "",
+				AuthorId:   1,
+				AuthorName: "",
+				GenreId:    1,
+				GenreName:  "",
+				Editions:   []Edition{{Format: FormatPaperback}},
+			},
+			valid: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -140,3 +216,51 @@ func TestBook_IsValid(t *testing.T) {
 	}
 
 }
+
+func TestBook_Validate(t *testing.T) {
+	book := &Book{
+		ISBN:     "978-0-306-40615-8",
+		Release:  time.Now().Add(time.Hour),
+		Pages:    200,
+		AuthorId: 1,
+		Editions: []Edition{
+			{Format: FormatEbook, Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local), Coast: 100},
+			{Format: FormatPaperback, Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local)},
+		},
+	}
+
+	err := book.Validate()
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Code: CodeRequired, Message: "Book name is require field"},
+		{Field: "isbn", Code: CodeInvalid, Message: "ISBN is invalid"},
+		{Field: "release", Code: CodeNotInPast, Message: "Release date must be in past"},
+		{Field: "coast", Code: CodeRequired, Message: "Coast is require field"},
+		{Field: "genre_id", Code: CodeRequired, Message: "genre_id is require field"},
+		{Field: "editions[1].coast", Code: CodeRequired, Message: "Edition 2: Coast is require field"},
+		{Field: "editions[1].pages", Code: CodeRequired, Message: "Edition 2: Pages is require field"},
+	}, err)
+
+	ok, message := book.IsValid()
+	assert.False(t, ok)
+	assert.Equal(t, "Book name is require field", message)
+}
+
+func TestValidationErrors_Translate(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "pages", Code: CodeRequired, Message: "Pages is require field"},
+		{Field: "editions[0].release", Code: CodeNotInPast, Message: "Edition 1: Release date must be in past"},
+		{Field: "shelf", Code: CodeInvalid, Message: "Shelf is invalid"},
+	}
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "pages", Code: CodeRequired, Message: "Поле «кількість сторінок» обов'язкове"},
+		{Field: "editions[0].release", Code: CodeNotInPast, Message: "Дата в полі «дата виходу (видання 1)» має бути в минулому"},
+		{Field: "shelf", Code: CodeInvalid, Message: "Значення поля «shelf» некоректне"},
+	}, errs.Translate("uk_UA"))
+
+	assert.Equal(t, errs, errs.Translate("en-GB"))
+	assert.Equal(t, errs, errs.Translate("de"))
+	assert.True(t, SupportsLanguage("en"))
+	assert.True(t, SupportsLanguage("UK"))
+	assert.False(t, SupportsLanguage("de"))
+}
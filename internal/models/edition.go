@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+type Format string
+
+const (
+	FormatUnspecified Format = "unspecified"
+	FormatHardcover   Format = "hardcover"
+	FormatPaperback   Format = "paperback"
+	FormatEbook       Format = "ebook"
+	FormatAudiobook   Format = "audiobook"
+)
+
+func (f Format) IsValid() bool {
+	switch f {
+	case FormatUnspecified, FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook:
+		return true
+	}
+	return false
+}
+
+// HasPages reports whether editions in the format must give a page count.
+// Ebooks and audiobooks need not.
+func (f Format) HasPages() bool {
+	return f != FormatEbook && f != FormatAudiobook
+}
+
+// Edition is a published product of a book: a particular format with its
+// own ISBN, price, page count, release date and poster.
+type Edition struct {
+	Id        int64     `json:"id"`
+	BookId    int64     `json:"book_id"`
+	Format    Format    `json:"format"`
+	ISBN      string    `json:"isbn"`
+	Release   time.Time `json:"release"`
+	Coast     uint      `json:"coast"`
+	Pages     uint      `json:"pages"`
+	PosterURL string    `json:"poster_url"`
+}
+
+// Validate returns ValidationErrors listing every invalid field, if any.
+func (e *Edition) Validate() error {
+	var errs ValidationErrors
+
+	if !e.Format.IsValid() {
+		errs.add("format", CodeInvalid, "Format is invalid")
+	}
+
+	if e.ISBN != "" && !IsValidISBN(e.ISBN) {
+		errs.add("isbn", CodeInvalid, "ISBN is invalid")
+	}
+
+	if !e.Release.Before(time.Now()) {
+		errs.add("release", CodeNotInPast, "Release date must be in past")
+	}
+
+	if e.Coast == 0 {
+		errs.add("coast", CodeRequired, "Coast is require field")
+	}
+
+	if e.Pages == 0 && e.Format.HasPages() {
+		errs.add("pages", CodeRequired, "Pages is require field")
+	}
+
+	return errs.err()
+}
+
+// IsValid reports the first problem Validate finds.
+func (e *Edition) IsValid() (bool, string) {
+	return isValid(e.Validate())
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEdition_IsValid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		edition *Edition
+		valid   bool
+	}{
+		{
+			name: "valid edition",
+			edition: &Edition{
+				Format:  FormatPaperback,
+				ISBN:    "978-0-306-40615-7",
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:   250,
+				Pages:   200,
+			},
+			valid: true,
+		},
+		{
+			name: "valid ebook without pages",
+			edition: &Edition{
+				Format:  FormatEbook,
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:   100,
+			},
+			valid: true,
+		},
+		{
+			name: "invalid format",
+			edition: &Edition{
+				Format:  Format("scroll"),
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:   250,
+				Pages:   200,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid isbn",
+			edition: &Edition{
+				Format:  FormatPaperback,
+				ISBN:    "978-0-306-40615-8",
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:   250,
+				Pages:   200,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid release date",
+			edition: &Edition{
+				Format:  FormatPaperback,
+				Release: time.Now().Add(time.Hour * 48),
+				Coast:   250,
+				Pages:   200,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid coast",
+			edition: &Edition{
+				Format:  FormatPaperback,
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Pages:   200,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid pages",
+			edition: &Edition{
+				Format:  FormatHardcover,
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.Local),
+				Coast:   250,
+			},
+			valid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, message := tc.edition.IsValid()
+			if tc.valid {
+				assert.Empty(t, message)
+				assert.True(t, ok)
+			} else {
+				assert.NotEmpty(t, message)
+				assert.False(t, ok)
+			}
+		})
+	}
+}
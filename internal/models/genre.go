@@ -1,6 +1,35 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 type Genre struct {
-	Id   int64  `json:"id"`
-	Name string `json:"name"`
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize trims surrounding whitespace from the name.
+func (g *Genre) Normalize() {
+	g.Name = strings.TrimSpace(g.Name)
+}
+
+// Validate returns ValidationErrors listing the invalid fields, if any.
+func (g *Genre) Validate() error {
+	var errs ValidationErrors
+
+	if g.Name == "" {
+		errs.add("name", CodeRequired, "Genre name is require field")
+	}
+	errs.checkLength("name", g.Name, MaxNameLength, "Genre name is too long")
+
+	return errs.err()
+}
+
+// IsValid reports the first problem Validate finds.
+func (g *Genre) IsValid() (bool, string) {
+	return isValid(g.Validate())
 }
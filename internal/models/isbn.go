@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrISBNLength   = errors.New("ISBN must have 10 or 13 digits")
+	ErrISBNChecksum = errors.New("ISBN checksum is invalid")
+)
+
+// NormalizeISBN strips hyphens and spaces from an ISBN, checks its checksum
+// and returns it as ISBN-13. An empty string is returned unchanged.
+func NormalizeISBN(isbn string) (string, error) {
+	isbn = strings.NewReplacer("-", "", " ", "").Replace(isbn)
+	if isbn == "" {
+		return "", nil
+	}
+
+	switch len(isbn) {
+	case 10:
+		if !validISBN10(isbn) {
+			return "", ErrISBNChecksum
+		}
+		isbn13 := "978" + isbn[:9]
+		return isbn13 + string(isbn13CheckDigit(isbn13)), nil
+	case 13:
+		if !validISBN13(isbn) {
+			return "", ErrISBNChecksum
+		}
+		return isbn, nil
+	default:
+		return "", ErrISBNLength
+	}
+}
+
+// IsValidISBN reports whether isbn is a valid ISBN-10 or ISBN-13,
+// with or without hyphens.
+func IsValidISBN(isbn string) bool {
+	normalized, err := NormalizeISBN(isbn)
+	return err == nil && normalized != ""
+}
+
+func validISBN10(isbn string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		c := isbn[i]
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case i == 9 && (c == 'X' || c == 'x'):
+			digit = 10
+		default:
+			return false
+		}
+		sum += digit * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+func validISBN13(isbn string) bool {
+	for i := 0; i < 13; i++ {
+		if isbn[i] < '0' || isbn[i] > '9' {
+			return false
+		}
+	}
+	return isbn13CheckDigit(isbn[:12]) == isbn[12]
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(digits[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
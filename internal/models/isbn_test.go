@@ -0,0 +1,69 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		isbn     string
+		expected string
+		err      error
+	}{
+		{
+			name:     "empty",
+			isbn:     "",
+			expected: "",
+		},
+		{
+			name:     "isbn-13",
+			isbn:     "9780306406157",
+			expected: "9780306406157",
+		},
+		{
+			name:     "isbn-13 with hyphens",
+			isbn:     "978-0-306-40615-7",
+			expected: "9780306406157",
+		},
+		{
+			name:     "isbn-10 upgraded to isbn-13",
+			isbn:     "0-306-40615-2",
+			expected: "9780306406157",
+		},
+		{
+			name:     "isbn-10 with X check digit",
+			isbn:     "0-8044-2957-X",
+			expected: "9780804429573",
+		},
+		{
+			name: "isbn-13 bad checksum",
+			isbn: "9780306406158",
+			err:  ErrISBNChecksum,
+		},
+		{
+			name: "isbn-10 bad checksum",
+			isbn: "0306406153",
+			err:  ErrISBNChecksum,
+		},
+		{
+			name: "letters",
+			isbn: "97803064061AB",
+			err:  ErrISBNChecksum,
+		},
+		{
+			name: "wrong length",
+			isbn: "12345",
+			err:  ErrISBNLength,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			isbn, err := NormalizeISBN(tc.isbn)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.expected, isbn)
+		})
+	}
+}
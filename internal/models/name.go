@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NameFormat selects how an author's name is displayed.
+type NameFormat string
+
+const (
+	// NameLastFirst renders "Tolkien John Ronald".
+	NameLastFirst NameFormat = "last_first"
+	// NameFirstLast renders "John Ronald Tolkien".
+	NameFirstLast NameFormat = "first_last"
+	// NameLastInitials renders "Tolkien, J. R.".
+	NameLastInitials NameFormat = "last_initials"
+)
+
+func (f NameFormat) IsValid() bool {
+	switch f {
+	case NameLastFirst, NameFirstLast, NameLastInitials:
+		return true
+	}
+	return false
+}
+
+// ParseNameFormat parses the name of a NameFormat, as used in flags.
+func ParseNameFormat(s string) (NameFormat, error) {
+	f := NameFormat(s)
+	if !f.IsValid() {
+		return "", fmt.Errorf("unknown name format %q, want %s, %s or %s", s, NameLastFirst, NameFirstLast, NameLastInitials)
+	}
+	return f, nil
+}
+
+// FormatName composes a display name from its parts. A pen name is what the
+// author publishes under, so it is shown as is whatever the format. The zero
+// format is NameLastFirst.
+func FormatName(f NameFormat, lastName, firstName, middleName, penName string) string {
+	if penName != "" {
+		return penName
+	}
+
+	switch f {
+	case NameFirstLast:
+		return joinNonEmpty(" ", firstName, middleName, lastName)
+	case NameLastInitials:
+		return joinNonEmpty(", ", lastName, joinNonEmpty(" ", initial(firstName), initial(middleName)))
+	default:
+		return joinNonEmpty(" ", lastName, firstName, middleName)
+	}
+}
+
+// DisplayName returns the author's name in format f.
+func (a *Author) DisplayName(f NameFormat) string {
+	return FormatName(f, a.LastName, a.FirstName, a.MiddleName, a.PenName)
+}
+
+// initial returns the first letter of name followed by a dot.
+func initial(name string) string {
+	for _, r := range name {
+		return string(unicode.ToUpper(r)) + "."
+	}
+	return ""
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
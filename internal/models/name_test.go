@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuthor_DisplayName(t *testing.T) {
+	tolkien := &Author{LastName: "Tolkien", FirstName: "John", MiddleName: "ronald"}
+	potter := &Author{LastName: "Potter", FirstName: "Harry"}
+	twain := &Author{LastName: "Clemens", FirstName: "Samuel", PenName: "Mark Twain"}
+
+	testCases := []struct {
+		name   string
+		author *Author
+		format NameFormat
+		want   string
+	}{
+		{name: "last first", author: tolkien, format: NameLastFirst, want: "Tolkien John ronald"},
+		{name: "first last", author: tolkien, format: NameFirstLast, want: "John ronald Tolkien"},
+		{name: "last initials", author: tolkien, format: NameLastInitials, want: "Tolkien, J. R."},
+		{name: "zero format", author: potter, format: "", want: "Potter Harry"},
+		{name: "without middle name", author: potter, format: NameLastInitials, want: "Potter, H."},
+		{name: "pen name", author: twain, format: NameFirstLast, want: "Mark Twain"},
+		{name: "last name only", author: &Author{LastName: "Homer"}, format: NameLastInitials, want: "Homer"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.author.DisplayName(tc.format))
+		})
+	}
+}
+
+func TestParseNameFormat(t *testing.T) {
+	f, err := ParseNameFormat("first_last")
+	assert.NoError(t, err)
+	assert.Equal(t, NameFirstLast, f)
+
+	_, err = ParseNameFormat("first")
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// BookPatch is a JSON Merge Patch (RFC 7396) of a book. Absent fields are
+// left untouched and null clears a field; null is rejected for the fields a
+// book requires.
+type BookPatch struct {
+	Name      *string    `json:"name"`
+	ISBN      *string    `json:"isbn"`
+	Release   *time.Time `json:"release"`
+	Coast     *uint      `json:"coast"`
+	Pages     *uint      `json:"pages"`
+	PosterURL *string    `json:"poster_url"`
+	AuthorId  *int64     `json:"author_id"`
+	GenreId   *int64     `json:"genre_id"`
+
+	nulls map[string]bool
+}
+
+func (p *BookPatch) UnmarshalJSON(data []byte) error {
+	type fields BookPatch
+	nulls, err := decodeMergePatch(data, (*fields)(p))
+	p.nulls = nulls
+	return err
+}
+
+// Apply changes b as the patch says. It returns ValidationErrors, leaving b
+// untouched, when the patch sets a required field to null.
+func (p *BookPatch) Apply(b *Book) error {
+	if err := requireNotNull(p.nulls, []FieldError{
+		{Field: "name", Message: "Book name is require field"},
+		{Field: "release", Message: "Release date is require field"},
+		{Field: "coast", Message: "Coast is require field"},
+		{Field: "pages", Message: "Pages is require field"},
+		{Field: "author_id", Message: "author_id is require field"},
+		{Field: "genre_id", Message: "genre_id is require field"},
+	}); err != nil {
+		return err
+	}
+
+	if p.Name != nil {
+		b.Name = *p.Name
+	}
+	if p.ISBN != nil || p.nulls["isbn"] {
+		b.ISBN = stringOrEmpty(p.ISBN)
+	}
+	if p.Release != nil {
+		b.Release = *p.Release
+	}
+	if p.Coast != nil {
+		b.Coast = *p.Coast
+	}
+	if p.Pages != nil {
+		b.Pages = *p.Pages
+	}
+	if p.PosterURL != nil || p.nulls["poster_url"] {
+		b.PosterURL = stringOrEmpty(p.PosterURL)
+	}
+	if p.AuthorId != nil {
+		b.AuthorId = *p.AuthorId
+	}
+	if p.GenreId != nil {
+		b.GenreId = *p.GenreId
+	}
+	return nil
+}
+
+// AuthorPatch is a JSON Merge Patch (RFC 7396) of an author. Absent fields
+// are left untouched and null clears a field, so a death date set by mistake
+// can be removed; null is rejected for the last and first name.
+type AuthorPatch struct {
+	LastName    *string    `json:"last_name"`
+	FirstName   *string    `json:"first_name"`
+	MiddleName  *string    `json:"middle_name"`
+	PenName     *string    `json:"pen_name"`
+	BirthDay    *time.Time `json:"birth_day"`
+	DeathDay    *time.Time `json:"death_day"`
+	Bio         *string    `json:"bio"`
+	Nationality *string    `json:"nationality"`
+	PortraitURL *string    `json:"portrait_url"`
+	Website     *string    `json:"website"`
+	SocialLinks *[]string  `json:"social_links"`
+	Slug        *string    `json:"slug"`
+
+	nulls map[string]bool
+}
+
+func (p *AuthorPatch) UnmarshalJSON(data []byte) error {
+	type fields AuthorPatch
+	nulls, err := decodeMergePatch(data, (*fields)(p))
+	p.nulls = nulls
+	return err
+}
+
+// Apply changes a as the patch says. It returns ValidationErrors, leaving a
+// untouched, when the patch sets a required field to null.
+func (p *AuthorPatch) Apply(a *Author) error {
+	if err := requireNotNull(p.nulls, []FieldError{
+		{Field: "last_name", Message: "Last name is require field"},
+		{Field: "first_name", Message: "First name is require field"},
+		{Field: "birth_day", Message: "Birth date is require field"},
+	}); err != nil {
+		return err
+	}
+
+	if p.LastName != nil {
+		a.LastName = *p.LastName
+	}
+	if p.FirstName != nil {
+		a.FirstName = *p.FirstName
+	}
+	if p.MiddleName != nil || p.nulls["middle_name"] {
+		a.MiddleName = stringOrEmpty(p.MiddleName)
+	}
+	if p.PenName != nil || p.nulls["pen_name"] {
+		a.PenName = stringOrEmpty(p.PenName)
+	}
+	if p.BirthDay != nil {
+		a.BirthDay = *p.BirthDay
+	}
+	if p.DeathDay != nil || p.nulls["death_day"] {
+		a.DeathDay = p.DeathDay
+	}
+	if p.Bio != nil || p.nulls["bio"] {
+		a.Bio = stringOrEmpty(p.Bio)
+	}
+	if p.Nationality != nil || p.nulls["nationality"] {
+		a.Nationality = stringOrEmpty(p.Nationality)
+	}
+	if p.PortraitURL != nil || p.nulls["portrait_url"] {
+		a.PortraitURL = stringOrEmpty(p.PortraitURL)
+	}
+	if p.Website != nil || p.nulls["website"] {
+		a.Website = stringOrEmpty(p.Website)
+	}
+	if p.SocialLinks != nil {
+		a.SocialLinks = *p.SocialLinks
+	} else if p.nulls["social_links"] {
+		a.SocialLinks = nil
+	}
+	if p.Slug != nil || p.nulls["slug"] {
+		a.Slug = stringOrEmpty(p.Slug)
+	}
+	return nil
+}
+
+// decodeMergePatch decodes a patch document into fields, rejecting unknown
+// members, and returns the names of the members that are null.
+func decodeMergePatch(data []byte, fields interface{}) (map[string]bool, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(fields); err != nil {
+		return nil, err
+	}
+
+	nulls := make(map[string]bool)
+	for name, value := range members {
+		if string(bytes.TrimSpace(value)) == "null" {
+			nulls[name] = true
+		}
+	}
+	return nulls, nil
+}
+
+// requireNotNull returns a required error for each of fields that is null.
+func requireNotNull(nulls map[string]bool, fields []FieldError) error {
+	var errs ValidationErrors
+	for _, f := range fields {
+		if nulls[f.Field] {
+			errs.add(f.Field, CodeRequired, f.Message)
+		}
+	}
+	return errs.err()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBookPatch_Apply(t *testing.T) {
+	original := Book{
+		Id:        1,
+		Name:      "Book",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     250,
+		Pages:     200,
+		PosterURL: "img.png",
+		AuthorId:  1,
+		GenreId:   1,
+	}
+
+	testCases := []struct {
+		name     string
+		patch    string
+		expected func(b *Book)
+	}{
+		{
+			name:     "empty patch",
+			patch:    `{}`,
+			expected: func(b *Book) {},
+		},
+		{
+			name:     "name only",
+			patch:    `{"name":"Fixed typo"}`,
+			expected: func(b *Book) { b.Name = "Fixed typo" },
+		},
+		{
+			name:  "several fields",
+			patch: `{"coast":300,"pages":0,"genre_id":2}`,
+			expected: func(b *Book) {
+				b.Coast = 300
+				b.Pages = 0
+				b.GenreId = 2
+			},
+		},
+		{
+			name:     "null clears optional field",
+			patch:    `{"poster_url":null}`,
+			expected: func(b *Book) { b.PosterURL = "" },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p BookPatch
+			assert.NoError(t, json.Unmarshal([]byte(tc.patch), &p))
+
+			actual := original
+			assert.NoError(t, p.Apply(&actual))
+
+			expected := original
+			tc.expected(&expected)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestAuthorPatch_Apply(t *testing.T) {
+	author := Author{LastName: "Potter", FirstName: "Harry", Bio: "bio"}
+
+	var p AuthorPatch
+	assert.NoError(t, json.Unmarshal([]byte(`{"bio":"new bio"}`), &p))
+	assert.NoError(t, p.Apply(&author))
+
+	assert.Equal(t, Author{LastName: "Potter", FirstName: "Harry", Bio: "new bio"}, author)
+}
+
+func TestAuthorPatch_ApplyNull(t *testing.T) {
+	death := time.Date(2001, 5, 11, 0, 0, 0, 0, time.UTC)
+	original := Author{LastName: "Adams", FirstName: "Douglas", Bio: "bio", DeathDay: &death, SocialLinks: []string{"https://example.com"}}
+
+	testCases := []struct {
+		name     string
+		patch    string
+		expected Author
+		err      bool
+	}{
+		{
+			name:     "clear death date",
+			patch:    `{"death_day":null}`,
+			expected: Author{LastName: "Adams", FirstName: "Douglas", Bio: "bio", SocialLinks: []string{"https://example.com"}},
+		},
+		{
+			name:     "clear bio and links",
+			patch:    `{"bio":null,"social_links":null}`,
+			expected: Author{LastName: "Adams", FirstName: "Douglas", DeathDay: &death},
+		},
+		{
+			name:     "null required field",
+			patch:    `{"bio":null,"last_name":null}`,
+			expected: original,
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p AuthorPatch
+			assert.NoError(t, json.Unmarshal([]byte(tc.patch), &p))
+
+			actual := original
+			err := p.Apply(&actual)
+			if tc.err {
+				assert.Equal(t, ValidationErrors{{Field: "last_name", Code: CodeRequired, Message: "Last name is require field"}}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	var p AuthorPatch
+	assert.NoError(t, json.Unmarshal([]byte(`{"birth_day":null}`), &p))
+	actual := original
+	assert.Equal(t, ValidationErrors{{Field: "birth_day", Code: CodeRequired, Message: "Birth date is require field"}}, p.Apply(&actual))
+	assert.Equal(t, original, actual)
+}
+
+func TestBookPatch_UnknownField(t *testing.T) {
+	var p BookPatch
+	assert.Error(t, json.Unmarshal([]byte(`{"nmae":"typo"}`), &p))
+}
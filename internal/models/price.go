@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// PricePeriod is an interval during which a book was sold at Coast.
+// EffectiveFrom is nil when the price predates the recorded history and
+// EffectiveTo is nil while the price is still current.
+type PricePeriod struct {
+	Coast         uint       `json:"coast"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
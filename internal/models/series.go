@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+// Series is an ordered set of books, e.g. "The Expanse".
+type Series struct {
+	Id          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Volumes     []SeriesBook `json:"volumes,omitempty"`
+}
+
+// SeriesBook is a book at a position in a series. Positions are fractional
+// so novellas can sit between numbered volumes, e.g. 2.5.
+type SeriesBook struct {
+	Position float64 `json:"position"`
+	Book     Book    `json:"book"`
+}
+
+// SeriesVolume places a book within a series, with links to the previous
+// and next volumes when they exist.
+type SeriesVolume struct {
+	SeriesId   int64   `json:"series_id"`
+	SeriesName string  `json:"series_name"`
+	Position   float64 `json:"position"`
+	PrevBookId *int64  `json:"prev_book_id,omitempty"`
+	NextBookId *int64  `json:"next_book_id,omitempty"`
+}
+
+// Normalize trims surrounding whitespace from the name.
+func (s *Series) Normalize() {
+	s.Name = strings.TrimSpace(s.Name)
+}
+
+// Validate returns ValidationErrors listing the invalid fields, if any.
+func (s *Series) Validate() error {
+	var errs ValidationErrors
+
+	if s.Name == "" {
+		errs.add("name", CodeRequired, "Series name is require field")
+	}
+	errs.checkLength("name", s.Name, MaxNameLength, "Series name is too long")
+
+	return errs.err()
+}
+
+// IsValid reports the first problem Validate finds.
+func (s *Series) IsValid() (bool, string) {
+	return isValid(s.Validate())
+}
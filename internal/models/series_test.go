@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSeries_IsValid(t *testing.T) {
+	ok, message := (&Series{Name: "The Expanse"}).IsValid()
+	assert.True(t, ok)
+	assert.Empty(t, message)
+
+	ok, message = (&Series{}).IsValid()
+	assert.False(t, ok)
+	assert.NotEmpty(t, message)
+}
+
+func TestSeries_Validate(t *testing.T) {
+	s := &Series{Name: "  "}
+	s.Normalize()
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Code: CodeRequired, Message: "Series name is require field"},
+	}, s.Validate())
+
+	s.Name = strings.Repeat("n", MaxNameLength+1)
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Code: CodeTooLong, Message: "Series name is too long"},
+	}, s.Validate())
+}
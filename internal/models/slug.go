@@ -0,0 +1,30 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify turns s into a slug: lower case letters and digits with words
+// separated by single hyphens.
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// IsValidSlug reports whether s is a slug as produced by Slugify.
+func IsValidSlug(s string) bool {
+	return s != "" && Slugify(s) == s
+}
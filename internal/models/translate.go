@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// catalog holds the validation messages of one language. A message is the
+// template of its code filled in with the label of its field.
+type catalog struct {
+	codes  map[string]string
+	labels map[string]string
+}
+
+var catalogs = map[string]catalog{
+	"uk": {
+		codes: map[string]string{
+			CodeRequired:   "Поле «%s» обов'язкове",
+			CodeTooLong:    "Значення поля «%s» задовге",
+			CodeInvalid:    "Значення поля «%s» некоректне",
+			CodeNotInPast:  "Дата в полі «%s» має бути в минулому",
+			CodeOutOfRange: "Значення поля «%s» поза допустимими межами",
+			CodeTaken:      "Значення поля «%s» вже використовується",
+		},
+		labels: map[string]string{
+			"name":         "назва",
+			"isbn":         "ISBN",
+			"release":      "дата виходу",
+			"coast":        "ціна",
+			"pages":        "кількість сторінок",
+			"poster_url":   "постер",
+			"author_id":    "автор",
+			"genre_id":     "жанр",
+			"format":       "формат",
+			"editions":     "видання",
+			"last_name":    "прізвище",
+			"first_name":   "ім'я",
+			"middle_name":  "по батькові",
+			"pen_name":     "псевдонім",
+			"birth_day":    "дата народження",
+			"death_day":    "дата смерті",
+			"bio":          "біографія",
+			"nationality":  "громадянство",
+			"portrait_url": "портрет",
+			"website":      "вебсайт",
+			"social_links": "соціальні мережі",
+			"slug":         "slug",
+		},
+	},
+}
+
+// SupportsLanguage reports whether validation messages can be translated to
+// lang, a language tag such as "uk" or "uk-UA". English is always supported.
+func SupportsLanguage(lang string) bool {
+	lang = baseLanguage(lang)
+	_, ok := catalogs[lang]
+	return ok || lang == "en"
+}
+
+// Translate returns the errors with their messages in lang. Fields and codes
+// are kept; English and unknown languages keep the original messages.
+func (e ValidationErrors) Translate(lang string) ValidationErrors {
+	c, ok := catalogs[baseLanguage(lang)]
+	if !ok {
+		return e
+	}
+
+	translated := make(ValidationErrors, len(e))
+	for i, fe := range e {
+		translated[i] = fe
+		if template, ok := c.codes[fe.Code]; ok {
+			translated[i].Message = fmt.Sprintf(template, c.label(fe.Field))
+		}
+	}
+	return translated
+}
+
+// label names a field path such as "editions[0].format" as
+// "формат (видання 1)".
+func (c catalog) label(field string) string {
+	parts := strings.Split(field, ".")
+	name, _ := splitIndex(parts[len(parts)-1])
+	label := c.labelOf(name)
+
+	for _, part := range parts[:len(parts)-1] {
+		if name, index := splitIndex(part); index >= 0 {
+			label += fmt.Sprintf(" (%s %d)", c.labelOf(name), index+1)
+		}
+	}
+	return label
+}
+
+func (c catalog) labelOf(name string) string {
+	if label, ok := c.labels[name]; ok {
+		return label
+	}
+	return name
+}
+
+// splitIndex splits "editions[0]" into "editions" and 0. The index is -1
+// when part has none.
+func splitIndex(part string) (string, int) {
+	open := strings.IndexByte(part, '[')
+	if open < 0 || !strings.HasSuffix(part, "]") {
+		return part, -1
+	}
+	index, err := strconv.Atoi(part[open+1 : len(part)-1])
+	if err != nil {
+		return part, -1
+	}
+	return part[:open], index
+}
+
+func baseLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
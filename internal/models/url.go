@@ -0,0 +1,12 @@
+package models
+
+import "net/url"
+
+// IsValidURL reports whether s is an absolute http or https URL.
+func IsValidURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
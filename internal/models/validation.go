@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Validation error codes name the rule a field broke, so clients can react
+// to or translate an error without parsing its message.
+const (
+	CodeRequired   = "required"
+	CodeTooLong    = "too_long"
+	CodeInvalid    = "invalid"
+	CodeNotInPast  = "not_in_past"
+	CodeOutOfRange = "out_of_range"
+	CodeTaken      = "taken"
+)
+
+const (
+	// MaxNameLength limits names of authors and genres, in characters.
+	MaxNameLength = 100
+	// MaxBioLength limits author biographies, in characters.
+	MaxBioLength = 5000
+)
+
+// FieldError describes why one field, named as in JSON, is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every invalid field of a model. Validate methods
+// return it as their error.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationErrors) add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// nest adds the errors of a nested model, qualifying their fields with path
+// and prefixing their messages.
+func (e *ValidationErrors) nest(path, prefix string, err error) {
+	nested, ok := err.(ValidationErrors)
+	if !ok {
+		return
+	}
+	for _, fe := range nested {
+		e.add(path+"."+fe.Field, fe.Code, prefix+fe.Message)
+	}
+}
+
+// checkLength adds a too_long error when value exceeds max characters.
+func (e *ValidationErrors) checkLength(field, value string, max int, message string) {
+	if utf8.RuneCountInString(value) > max {
+		e.add(field, CodeTooLong, message)
+	}
+}
+
+// err returns nil when there are no errors, so callers never get a non-nil
+// error holding an empty list.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// isValid adapts the result of a Validate method to the IsValid convention,
+// reporting the first failing field.
+func isValid(err error) (bool, string) {
+	if err == nil {
+		return true, ""
+	}
+	if errs, ok := err.(ValidationErrors); ok && len(errs) > 0 {
+		return false, errs[0].Message
+	}
+	return false, err.Error()
+}
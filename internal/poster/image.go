@@ -0,0 +1,123 @@
+package poster
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	// MaxBytes is the largest poster file accepted.
+	MaxBytes = 10 << 20
+	// MaxDimension bounds the width and height of a poster, so a small file
+	// cannot decode into a huge image.
+	MaxDimension = 8000
+)
+
+var (
+	ErrTooLarge        = errors.New("poster must not be larger than 10 MB")
+	ErrUnsupportedType = errors.New("poster must be a JPEG, PNG or WebP image")
+	ErrTooManyPixels   = errors.New("poster must not be wider or taller than 8000 pixels")
+)
+
+// extensions maps the accepted content types to file extensions.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Size is a thumbnail size. Thumbnails keep the aspect ratio of the poster.
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Sizes lists the thumbnails made for every poster.
+var Sizes = []Size{
+	{Name: "small", Width: 160},
+	{Name: "medium", Width: 480},
+}
+
+// poster is an uploaded image that passed the type and size checks.
+type poster struct {
+	data        []byte
+	contentType string
+	image       image.Image
+}
+
+// readPoster reads at most MaxBytes from r and decodes the image, checking
+// its type by content rather than by the name or header the client sent.
+func readPoster(r io.Reader) (*poster, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	return &poster{data: data, contentType: contentType, image: img}, nil
+}
+
+// thumbnailExt is the extension of the thumbnails of a poster. JPEG posters
+// get JPEG thumbnails; PNG and WebP ones get PNG so transparency survives.
+func thumbnailExt(contentType string) string {
+	if contentType == "image/jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// thumbnail scales the poster down to size and encodes it in the format
+// thumbnailExt names. Posters narrower than size are not scaled up.
+func (p *poster) thumbnail(size Size) ([]byte, error) {
+	bounds := p.image.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size.Width {
+		height = height * size.Width / width
+		width = size.Width
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), p.image, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	var err error
+	if thumbnailExt(p.contentType) == ".jpg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,252 @@
+// Package poster accepts uploaded book posters, stores them with their
+// thumbnails in a blob store and removes the files no book refers to.
+package poster
+
+import (
+	"bookland/internal/blob"
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keyPrefix is where posters are kept. The files of a book live below
+// keyPrefix + "{book id}/".
+const keyPrefix = "posters/"
+
+// Posters keeps the poster files of books. Uploads and clean-ups hold mu,
+// so a clean-up never sees the files of an upload the book does not refer
+// to yet.
+type Posters struct {
+	store *store.Store
+	blobs blob.Store
+	mu    *sync.Mutex
+}
+
+func New(s *store.Store, blobs blob.Store) *Posters {
+	return &Posters{store: s, blobs: blobs, mu: &sync.Mutex{}}
+}
+
+// WithStore returns Posters that update books through s, e.g. a store
+// attributing the change to an actor.
+func (p *Posters) WithStore(s *store.Store) *Posters {
+	return &Posters{store: s, blobs: p.blobs, mu: p.mu}
+}
+
+func bookPrefix(id int) string {
+	return keyPrefix + strconv.Itoa(id) + "/"
+}
+
+// thumbnailKey names the thumbnail of size made for the poster stored under key.
+func thumbnailKey(key, contentType string, size Size) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "-" + size.Name + thumbnailExt(contentType)
+}
+
+// Thumbnails returns the URL of every thumbnail of a poster uploaded through
+// p, keyed by size name, or nil when posterURL is not such a poster.
+func (p *Posters) Thumbnails(posterURL string) map[string]string {
+	key, ok := p.key(posterURL)
+	if !ok {
+		return nil
+	}
+
+	contentType := "image/png"
+	if path.Ext(key) == ".jpg" {
+		contentType = "image/jpeg"
+	}
+	urls := map[string]string{}
+	for _, size := range Sizes {
+		urls[size.Name] = p.blobs.URL(thumbnailKey(key, contentType, size))
+	}
+	return urls
+}
+
+// key returns the blob key of a poster URL, reporting false for URLs that
+// do not point into the blob store.
+func (p *Posters) key(posterURL string) (string, bool) {
+	base := strings.TrimSuffix(p.blobs.URL(keyPrefix), keyPrefix)
+	if !strings.HasPrefix(posterURL, base+keyPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(posterURL, base), true
+}
+
+// Upload stores the image read from r as the poster of the book, together
+// with its thumbnails, and points the book's PosterURL at it. version is the
+// book version the client read, as for Books.Patch. The files of the
+// previous poster are removed once the book refers to the new one.
+func (p *Posters) Upload(id int, version int64, r io.Reader) (*models.Book, error) {
+	img, err := readPoster(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := bookPrefix(id) + name + extensions[img.contentType]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys, err := p.put(key, img)
+	if err != nil {
+		p.deleteKeys(keys)
+		return nil, err
+	}
+
+	url := p.blobs.URL(key)
+	book, err := p.store.Books.Patch(id, version, &models.BookPatch{PosterURL: &url})
+	if err != nil {
+		p.deleteKeys(keys)
+		return nil, err
+	}
+
+	if _, err := p.removeStale(id); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// put stores the poster and its thumbnails, returning the keys written so
+// far even when it fails.
+func (p *Posters) put(key string, img *poster) ([]string, error) {
+	if err := p.blobs.Put(key, bytes.NewReader(img.data)); err != nil {
+		return nil, err
+	}
+	keys := []string{key}
+
+	for _, size := range Sizes {
+		data, err := img.thumbnail(size)
+		if err != nil {
+			return keys, err
+		}
+		thumbKey := thumbnailKey(key, img.contentType, size)
+		if err := p.blobs.Put(thumbKey, bytes.NewReader(data)); err != nil {
+			return keys, err
+		}
+		keys = append(keys, thumbKey)
+	}
+	return keys, nil
+}
+
+// deleteKeys undoes a failed upload. Errors are ignored: the files left
+// behind are orphans RemoveOrphans collects later.
+func (p *Posters) deleteKeys(keys []string) {
+	for _, key := range keys {
+		p.blobs.Delete(key)
+	}
+}
+
+// removeStale deletes the files of the book that belong to neither its
+// current poster nor its thumbnails, and returns how many it deleted. The
+// poster is read after listing the files, so an upload that replaced it in
+// the meantime keeps its files. All files of purged books are deleted.
+func (p *Posters) removeStale(id int) (int, error) {
+	keys, err := p.blobs.List(bookPrefix(id))
+	if err != nil {
+		return 0, err
+	}
+	return p.deleteStale(id, keys)
+}
+
+// deleteStale deletes the keys of the book that belong to neither its
+// current poster nor its thumbnails. keys must be listed before the call.
+func (p *Posters) deleteStale(id int, keys []string) (int, error) {
+	posterURL, err := p.store.Books.Poster(id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	keep := map[string]bool{}
+	if key, ok := p.key(posterURL); ok {
+		keep[key] = true
+		for _, url := range p.Thumbnails(posterURL) {
+			thumbKey, _ := p.key(url)
+			keep[thumbKey] = true
+		}
+	}
+
+	var removed int
+	for _, key := range keys {
+		if keep[key] {
+			continue
+		}
+		if err := p.blobs.Delete(key); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RemoveOrphans deletes poster files that no book refers to any more:
+// files of purged books and files left behind by replaced posters or failed
+// uploads. Books in the trash keep their files so they can be restored.
+// It returns the number of files deleted.
+func (p *Posters) RemoveOrphans() (int, error) {
+	keys, err := p.blobs.List(keyPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	// The tree is listed once and grouped by book rather than listed again
+	// for every book.
+	var ids []int
+	byBook := map[int][]string{}
+	for _, key := range keys {
+		dir := strings.SplitN(strings.TrimPrefix(key, keyPrefix), "/", 2)[0]
+		id, err := strconv.Atoi(dir)
+		if err != nil {
+			continue
+		}
+		if _, ok := byBook[id]; !ok {
+			ids = append(ids, id)
+		}
+		byBook[id] = append(byBook[id], key)
+	}
+
+	var removed int
+	for _, id := range ids {
+		p.mu.Lock()
+		n, err := p.deleteStale(id, byBook[id])
+		p.mu.Unlock()
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Purge purges the store as store.Store.Purge does and then deletes the
+// poster files of the purged books.
+func (p *Posters) Purge(retention time.Duration) (books int, authors int, files int, err error) {
+	books, authors, err = p.store.Purge(retention)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	files, err = p.RemoveOrphans()
+	if err != nil {
+		return books, authors, files, fmt.Errorf("remove poster files: %w", err)
+	}
+	return books, authors, files, nil
+}
+
+func randomName() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
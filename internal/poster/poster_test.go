@@ -0,0 +1,199 @@
+package poster
+
+import (
+	"bookland/internal/blob"
+	"bookland/internal/fixture"
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"bytes"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newTestPosters(t *testing.T) (*Posters, *blob.LocalStore, *store.Store) {
+	conn := fixture.NewTestSQLiteDB(t)
+
+	blobs, err := blob.NewLocalStore(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	s := store.NewStore(conn)
+	return New(s, blobs), blobs, s
+}
+
+func imageSize(t *testing.T, blobs blob.Store, key string) (int, int) {
+	r, err := blobs.Open(key)
+	if !assert.NoError(t, err) {
+		return 0, 0
+	}
+	defer r.Close()
+	config, _, err := image.DecodeConfig(r)
+	assert.NoError(t, err)
+	return config.Width, config.Height
+}
+
+func TestPosters_Upload(t *testing.T) {
+	var jpegData bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&jpegData, testImage(640, 960), nil))
+
+	testCases := []struct {
+		name     string
+		data     []byte
+		ext      string
+		thumbExt string
+	}{
+		{name: "png", data: encodePNG(t, testImage(640, 960)), ext: ".png", thumbExt: ".png"},
+		{name: "jpeg", data: jpegData.Bytes(), ext: ".jpg", thumbExt: ".jpg"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			posters, blobs, _ := newTestPosters(t)
+
+			book, err := posters.Upload(1, 1, bytes.NewReader(tc.data))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), book.Version)
+			assert.True(t, strings.HasPrefix(book.PosterURL, "/media/posters/1/"))
+			assert.True(t, strings.HasSuffix(book.PosterURL, tc.ext))
+
+			keys, err := blobs.List("posters/1/")
+			assert.NoError(t, err)
+			assert.Len(t, keys, 1+len(Sizes))
+
+			thumbnails := posters.Thumbnails(book.PosterURL)
+			assert.Len(t, thumbnails, len(Sizes))
+			for _, size := range Sizes {
+				key := strings.TrimPrefix(thumbnails[size.Name], "/media/")
+				assert.True(t, strings.HasSuffix(key, "-"+size.Name+tc.thumbExt))
+				width, height := imageSize(t, blobs, key)
+				assert.Equal(t, size.Width, width)
+				assert.Equal(t, size.Width*3/2, height)
+			}
+		})
+	}
+}
+
+func TestPosters_UploadRejected(t *testing.T) {
+	var gifData bytes.Buffer
+	assert.NoError(t, gif.Encode(&gifData, testImage(10, 10), nil))
+	huge := encodePNG(t, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1)))
+
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "gif", data: gifData.Bytes(), err: ErrUnsupportedType},
+		{name: "text", data: []byte("not an image"), err: ErrUnsupportedType},
+		{name: "truncated png", data: encodePNG(t, testImage(10, 10))[:20], err: ErrUnsupportedType},
+		{name: "too many pixels", data: huge, err: ErrTooManyPixels},
+		{name: "too large", data: append(encodePNG(t, testImage(10, 10)), make([]byte, MaxBytes)...), err: ErrTooLarge},
+		{name: "stale version", data: encodePNG(t, testImage(10, 10)), err: store.ErrConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			posters, blobs, s := newTestPosters(t)
+
+			version := int64(1)
+			if tc.err == store.ErrConflict {
+				version = 7
+			}
+			_, err := posters.Upload(1, version, bytes.NewReader(tc.data))
+			assert.Equal(t, tc.err, err)
+
+			keys, err := blobs.List("")
+			assert.NoError(t, err)
+			assert.Empty(t, keys)
+
+			book, err := s.Books.GetById(1)
+			assert.NoError(t, err)
+			assert.Equal(t, "img.png", book.PosterURL)
+		})
+	}
+}
+
+func TestPosters_ReplaceAndPurge(t *testing.T) {
+	posters, blobs, s := newTestPosters(t)
+
+	first, err := posters.Upload(1, 1, bytes.NewReader(encodePNG(t, testImage(20, 30))))
+	assert.NoError(t, err)
+	second, err := posters.Upload(1, first.Version, bytes.NewReader(encodePNG(t, testImage(30, 20))))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.PosterURL, second.PosterURL)
+
+	keys, err := blobs.List("posters/1/")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1+len(Sizes))
+	assert.Contains(t, keys, strings.TrimPrefix(second.PosterURL, "/media/"))
+
+	// A file left behind by a failed upload is an orphan.
+	assert.NoError(t, blobs.Put("posters/2/leftover.png", bytes.NewReader([]byte("x"))))
+	removed, err := posters.RemoveOrphans()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	// Books in the trash keep their poster until they are purged.
+	assert.NoError(t, s.Books.Delete(1, 1))
+	_, _, files, err := posters.Purge(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, files)
+
+	books, _, files, err := posters.Purge(-time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, books)
+	assert.Equal(t, 1+len(Sizes), files)
+
+	_, err = s.Books.Poster(1)
+	assert.Equal(t, sql.ErrNoRows, err)
+	keys, err = blobs.List("")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestPosters_RemoveStaleKeepsCurrentPoster(t *testing.T) {
+	posters, blobs, s := newTestPosters(t)
+
+	first, err := posters.Upload(1, 1, bytes.NewReader(encodePNG(t, testImage(20, 30))))
+	assert.NoError(t, err)
+
+	// Another upload replaces the poster before the first one cleans up.
+	img, err := readPoster(bytes.NewReader(encodePNG(t, testImage(30, 20))))
+	assert.NoError(t, err)
+	_, err = posters.put("posters/1/newer.png", img)
+	assert.NoError(t, err)
+	newer := blobs.URL("posters/1/newer.png")
+	_, err = s.Books.Patch(1, first.Version, &models.BookPatch{PosterURL: &newer})
+	assert.NoError(t, err)
+
+	removed, err := posters.removeStale(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1+len(Sizes), removed)
+
+	keys, err := blobs.List("posters/1/")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1+len(Sizes))
+	assert.Contains(t, keys, "posters/1/newer.png")
+}
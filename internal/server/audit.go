@@ -0,0 +1,48 @@
+package server
+
+import (
+	"bookland/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handleAudit serves GET /audit/{entity}/{id}, the change history of one
+// book, author, author alias or genre, newest first.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/audit/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	entity := parts[0]
+	if entity != "book" && entity != "author" && entity != "author_alias" && entity != "genre" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	perPage, page := pagination(r)
+	entries, err := s.store.Audit.History(entity, id, perPage, page)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bookland/internal/models"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// handleAuthor serves GET, PUT and PATCH /authors/{id} and GET /authors/{slug}.
+func (s *Server) handleAuthor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "/authors/")
+	if !ok {
+		slug := strings.TrimPrefix(r.URL.Path, "/authors/")
+		if r.Method == http.MethodGet && models.IsValidSlug(slug) {
+			s.getAuthorBySlug(w, slug)
+			return
+		}
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getAuthor(w, id)
+	case http.MethodPut:
+		s.putAuthor(w, r, id)
+	case http.MethodPatch:
+		s.patchAuthor(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) getAuthor(w http.ResponseWriter, id int) {
+	author, err := s.store.Authors.Get(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(author.Version))
+	writeJSON(w, http.StatusOK, author)
+}
+
+func (s *Server) getAuthorBySlug(w http.ResponseWriter, slug string) {
+	author, err := s.store.Authors.GetBySlug(slug)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(author.Version))
+	writeJSON(w, http.StatusOK, author)
+}
+
+func (s *Server) putAuthor(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	author := &models.Author{}
+	if err := json.NewDecoder(r.Body).Decode(author); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	author.Id = int64(id)
+	author.Version = version
+
+	if err := s.storeFor(r).Authors.Update(author); err != nil {
+		writeStoreError(w, localize(r, err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(author.Version))
+	writeJSON(w, http.StatusOK, author)
+}
+
+// patchAuthor applies a JSON Merge Patch (RFC 7396) to the author. Null
+// clears a field, such as a death date.
+func (s *Server) patchAuthor(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	patch := &models.AuthorPatch{}
+	if err := decodePatch(r, patch); err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	author, err := s.storeFor(r).Authors.Patch(id, version, patch)
+	if err != nil {
+		writeStoreError(w, localize(r, err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(author.Version))
+	writeJSON(w, http.StatusOK, author)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bookland/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handleBook serves GET, PUT and PATCH /books/{id}, GET /books/{id}/prices
+// and /books/{id}/poster.
+func (s *Server) handleBook(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/prices") {
+		s.handleBookPrices(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/poster") {
+		s.handleBookPoster(w, r)
+		return
+	}
+
+	id, ok := pathID(r, "/books/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+			s.getBookAsOf(w, id, asOf)
+			return
+		}
+		s.getBook(w, id)
+	case http.MethodPut:
+		s.putBook(w, r, id)
+	case http.MethodPatch:
+		s.patchBook(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) getBook(w http.ResponseWriter, id int) {
+	book, err := s.store.Books.GetById(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(book.Version))
+	writeJSON(w, http.StatusOK, book)
+}
+
+// getBookAsOf serves the book as it was at the RFC 3339 time asOf.
+func (s *Server) getBookAsOf(w http.ResponseWriter, id int, asOf string) {
+	at, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "as_of must be an RFC 3339 time")
+		return
+	}
+
+	book, err := s.store.Books.GetByIdAsOf(id, at)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, book)
+}
+
+// handleBookPrices serves GET /books/{id}/prices.
+func (s *Server) handleBookPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/books/"), "/prices"))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	periods, err := s.store.Books.PriceHistory(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, periods)
+}
+
+func (s *Server) putBook(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	book := &models.Book{}
+	if err := json.NewDecoder(r.Body).Decode(book); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	book.Id = int64(id)
+	book.Version = version
+
+	if err := s.storeFor(r).Books.Update(book); err != nil {
+		writeStoreError(w, localize(r, err))
+		return
+	}
+
+	s.getBook(w, id)
+}
+
+// patchBook applies a JSON Merge Patch (RFC 7396) to the book. Only the
+// fields present in the body change and null clears a field; the result must
+// still pass Validate.
+func (s *Server) patchBook(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	patch := &models.BookPatch{}
+	if err := decodePatch(r, patch); err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	book, err := s.storeFor(r).Books.Patch(id, version, patch)
+	if err != nil {
+		writeStoreError(w, localize(r, err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(book.Version))
+	writeJSON(w, http.StatusOK, book)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"bookland/internal/models"
+	"encoding/json"
+	"net/http"
+)
+
+// handleGenre serves GET and PUT /genres/{id}.
+func (s *Server) handleGenre(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "/genres/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getGenre(w, id)
+	case http.MethodPut:
+		s.putGenre(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) getGenre(w http.ResponseWriter, id int) {
+	genre, err := s.store.Genres.Get(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(genre.Version))
+	writeJSON(w, http.StatusOK, genre)
+}
+
+func (s *Server) putGenre(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	genre := &models.Genre{}
+	if err := json.NewDecoder(r.Body).Decode(genre); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	genre.Id = int64(id)
+	genre.Version = version
+
+	if err := s.storeFor(r).Genres.Update(genre); err != nil {
+		writeStoreError(w, localize(r, err))
+		return
+	}
+
+	w.Header().Set("ETag", etag(genre.Version))
+	writeJSON(w, http.StatusOK, genre)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds how long /readyz waits for the health check.
+const readyTimeout = 2 * time.Second
+
+// healthBody is the body of the health endpoints. It never carries the
+// error of a failed check, which may reveal database details; that is
+// logged instead.
+type healthBody struct {
+	Status string `json:"status"`
+}
+
+// WithHealthCheck makes /readyz report whether check passes, e.g. whether
+// the database answers.
+func (s *Server) WithHealthCheck(check func(ctx context.Context) error) *Server {
+	s.healthCheck = check
+	return s
+}
+
+// handleLive serves /healthz, which answers as long as the process serves
+// requests at all.
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, healthBody{Status: "ok"})
+}
+
+// handleReady serves /readyz, which fails while the health check does, so
+// a load balancer stops sending traffic to a server that cannot reach its
+// database.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if s.healthCheck != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if err := s.healthCheck(ctx); err != nil {
+			log.Printf("health check: %s\n", err)
+			writeJSON(w, http.StatusServiceUnavailable, healthBody{Status: "unavailable"})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, healthBody{Status: "ok"})
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bookland/internal/poster"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errNoPosterPart = errors.New(`multipart body must have a "poster" file`)
+
+// posterBody describes the poster of a book.
+type posterBody struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+}
+
+// handleBookPoster serves GET and PUT /books/{id}/poster. PUT takes the image
+// either as the raw request body or as the "poster" file of a multipart form.
+func (s *Server) handleBookPoster(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/books/"), "/poster"))
+	if err != nil || id <= 0 || s.posters == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		book, err := s.store.Books.GetById(id)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, posterBody{URL: book.PosterURL, Thumbnails: s.posters.Thumbnails(book.PosterURL)})
+	case http.MethodPut:
+		s.putBookPoster(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) putBookPoster(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	// Leave room for the multipart headers around the image.
+	r.Body = http.MaxBytesReader(w, r.Body, poster.MaxBytes+64<<10)
+	image, err := posterReader(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	book, err := s.posters.WithStore(s.storeFor(r)).Upload(id, version, image)
+	switch {
+	case errors.Is(err, poster.ErrTooLarge), errors.Is(err, poster.ErrTooManyPixels):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, poster.ErrUnsupportedType):
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+	case err != nil:
+		writeStoreError(w, localize(r, err))
+	default:
+		w.Header().Set("ETag", etag(book.Version))
+		writeJSON(w, http.StatusOK, book)
+	}
+}
+
+// posterReader returns the image of a poster upload.
+func posterReader(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errNoPosterPart
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "poster" {
+			return part, nil
+		}
+	}
+}
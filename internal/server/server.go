@@ -0,0 +1,204 @@
+// Package server exposes the store over HTTP as a JSON API.
+package server
+
+import (
+	"bookland/internal/models"
+	"bookland/internal/poster"
+	"bookland/internal/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errPreconditionRequired = errors.New("If-Match header is required")
+	errInvalidETag          = errors.New("If-Match header must be an ETag returned by GET")
+	errPatchContentType     = errors.New("PATCH body must be application/merge-patch+json")
+)
+
+type Server struct {
+	store       *store.Store
+	posters     *poster.Posters
+	healthCheck func(ctx context.Context) error
+	trustActor  bool
+	mux         *http.ServeMux
+}
+
+func New(s *store.Store) *Server {
+	srv := &Server{
+		store: s,
+		mux:   http.NewServeMux(),
+	}
+
+	srv.mux.HandleFunc("/books/", srv.handleBook)
+	srv.mux.HandleFunc("/authors/", srv.handleAuthor)
+	srv.mux.HandleFunc("/genres/", srv.handleGenre)
+	srv.mux.HandleFunc("/audit/", srv.handleAudit)
+	srv.mux.HandleFunc("/healthz", srv.handleLive)
+	srv.mux.HandleFunc("/readyz", srv.handleReady)
+
+	return srv
+}
+
+// WithPosters enables poster uploads at /books/{id}/poster.
+func (s *Server) WithPosters(p *poster.Posters) *Server {
+	s.posters = p
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ActorHeader names the request header identifying who makes a change.
+// It is recorded in the audit log, but only read when the server trusts it.
+const ActorHeader = "X-Actor"
+
+// WithTrustedActorHeader makes the server record the actor named in
+// ActorHeader in the audit log. The server does not authenticate anyone, so
+// any client can name any actor: enable this only behind a proxy that
+// authenticates users and sets the header itself, replacing the client's.
+func (s *Server) WithTrustedActorHeader() *Server {
+	s.trustActor = true
+	return s
+}
+
+// storeFor returns the store to write through for r, attributing the
+// changes to the actor named in the request when the header is trusted.
+func (s *Server) storeFor(r *http.Request) *store.Store {
+	if !s.trustActor {
+		return s.store
+	}
+	if actor := strings.TrimSpace(r.Header.Get(ActorHeader)); actor != "" {
+		return s.store.WithActor(actor)
+	}
+	return s.store
+}
+
+// pagination reads the page and per_page query parameters.
+func pagination(r *http.Request) (perPage, page int) {
+	perPage, page = 20, 1
+	if v, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && v > 0 && v <= 100 {
+		perPage = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	return perPage, page
+}
+
+// pathID parses the id from paths of the form prefix + "{id}".
+func pathID(r *http.Request, prefix string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, prefix))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// etag renders a row version as a strong ETag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion returns the row version the client read, taken from If-Match.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errPreconditionRequired
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errInvalidETag
+	}
+	return version, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("%s\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// decodePatch decodes an application/merge-patch+json body into a patch
+// struct, rejecting fields the resource does not have.
+func decodePatch(r *http.Request, patch interface{}) error {
+	contentType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	if contentType != "application/merge-patch+json" {
+		return errPatchContentType
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(patch)
+}
+
+func writePatchError(w http.ResponseWriter, err error) {
+	if err == errPatchContentType {
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// validationBody is the body of a 422 response, listing every invalid field.
+type validationBody struct {
+	Error  string                  `json:"error"`
+	Fields models.ValidationErrors `json:"fields"`
+}
+
+// localize translates the messages of validation errors to the first
+// language in the request's Accept-Language header that has translations.
+func localize(r *http.Request, err error) error {
+	var fieldErrs models.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	for _, lang := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang = strings.TrimSpace(strings.Split(lang, ";")[0])
+		if models.SupportsLanguage(lang) {
+			return fieldErrs.Translate(lang)
+		}
+	}
+	return fieldErrs
+}
+
+// writeStoreError maps store errors onto HTTP statuses.
+func writeStoreError(w http.ResponseWriter, err error) {
+	var fieldErrs models.ValidationErrors
+	switch {
+	case errors.As(err, &fieldErrs):
+		writeJSON(w, http.StatusUnprocessableEntity, validationBody{Error: fieldErrs.Error(), Fields: fieldErrs})
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, store.ErrConflict):
+		writeError(w, http.StatusPreconditionFailed, "resource was modified, reload it and retry")
+	case errors.Is(err, store.ErrAuthorDeleted), errors.Is(err, store.ErrISBNInUse):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("%s\n", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// writeVersionError answers a request whose If-Match header is missing or malformed.
+func writeVersionError(w http.ResponseWriter, err error) {
+	if err == errPreconditionRequired {
+		writeError(w, http.StatusPreconditionRequired, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
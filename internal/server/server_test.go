@@ -0,0 +1,606 @@
+package server
+
+import (
+	"bookland/internal/blob"
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/poster"
+	"bookland/internal/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer_BookETag(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	body := []byte(`{"name":"renamed","release":"2010-10-10T00:00:00Z","coast":400,"pages":150,"poster_url":"img.png","author_id":1,"genre_id":1}`)
+
+	testCases := []struct {
+		name    string
+		ifMatch string
+		status  int
+		etag    string
+	}{
+		{
+			name:    "missing If-Match",
+			ifMatch: "",
+			status:  http.StatusPreconditionRequired,
+		},
+		{
+			name:    "malformed If-Match",
+			ifMatch: "1",
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "current version",
+			ifMatch: `"1"`,
+			status:  http.StatusOK,
+			etag:    `"2"`,
+		},
+		{
+			name:    "stale version",
+			ifMatch: `"1"`,
+			status:  http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/books/1", bytes.NewReader(body))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.etag, rec.Header().Get("ETag"))
+		})
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1", nil))
+	var book struct {
+		Name    string `json:"name"`
+		Version int64  `json:"version"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&book))
+	assert.Equal(t, "renamed", book.Name)
+	assert.Equal(t, int64(2), book.Version)
+}
+
+func TestServer_NotFound(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	for _, path := range []string{"/books/99", "/books/abc", "/authors/99", "/genres/99"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
+
+func TestServer_AuthorAndGenreETag(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	testCases := []struct {
+		path string
+		body string
+	}{
+		{
+			path: "/authors/1",
+			body: `{"last_name":"Potter","first_name":"James","birth_day":"1960-01-01T00:00:00Z","bio":"bio"}`,
+		},
+		{
+			path: "/genres/1",
+			body: `{"name":"Fantasy"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("If-Match", `"1"`)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+			req = httptest.NewRequest(http.MethodPut, tc.path, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("If-Match", `"1"`)
+			rec = httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		})
+	}
+}
+
+func TestServer_PatchBook(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	testCases := []struct {
+		name        string
+		body        string
+		contentType string
+		ifMatch     string
+		status      int
+	}{
+		{
+			name:        "fix typo in name",
+			body:        `{"name":"fixed name"}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "stale version",
+			body:        `{"name":"other name"}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			status:      http.StatusPreconditionFailed,
+		},
+		{
+			name:        "invalid result",
+			body:        `{"coast":0}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"2"`,
+			status:      http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "null required field",
+			body:        `{"name":null}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"2"`,
+			status:      http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "unknown field",
+			body:        `{"nmae":"typo"}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"2"`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "plain json",
+			body:        `{"name":"fixed name"}`,
+			contentType: "application/json",
+			ifMatch:     `"2"`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "wrong content type",
+			body:        `{"name":"fixed name"}`,
+			contentType: "text/plain",
+			ifMatch:     `"2"`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "null clears poster",
+			body:        `{"poster_url":null}`,
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"2"`,
+			status:      http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/books/1", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set("If-Match", tc.ifMatch)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1", nil))
+	var book struct {
+		Name      string `json:"name"`
+		Coast     uint   `json:"coast"`
+		PosterURL string `json:"poster_url"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&book))
+	assert.Equal(t, "fixed name", book.Name)
+	assert.Equal(t, uint(300), book.Coast)
+	assert.Equal(t, "", book.PosterURL)
+}
+
+func TestServer_PatchAuthor(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	req := httptest.NewRequest(http.MethodPatch, "/authors/2", bytes.NewReader([]byte(`{"bio":"new bio"}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	var author struct {
+		LastName string `json:"last_name"`
+		Bio      string `json:"bio"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&author))
+	assert.Equal(t, "Laurence", author.LastName)
+	assert.Equal(t, "new bio", author.Bio)
+
+	for i, body := range []string{`{"death_day":"1990-05-01T00:00:00Z"}`, `{"death_day":null}`} {
+		req := httptest.NewRequest(http.MethodPatch, "/authors/2", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", etag(int64(i+2)))
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, body)
+	}
+
+	author2, err := store.NewStore(conn).Authors.Get(2)
+	assert.NoError(t, err)
+	assert.Nil(t, author2.DeathDay)
+}
+
+func TestServer_AuditHistory(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn)).WithTrustedActorHeader()
+
+	req := httptest.NewRequest(http.MethodPatch, "/books/1", bytes.NewReader([]byte(`{"coast":350}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	req.Header.Set(ActorHeader, "alice")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit/book/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	type entry struct {
+		Action string `json:"action"`
+		Actor  string `json:"actor"`
+		Diff   map[string]struct {
+			From interface{} `json:"from"`
+			To   interface{} `json:"to"`
+		} `json:"diff"`
+	}
+	var entries []entry
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, "update", entries[0].Action)
+		assert.Equal(t, "alice", entries[0].Actor)
+		assert.Equal(t, float64(300), entries[0].Diff["coast"].From)
+		assert.Equal(t, float64(350), entries[0].Diff["coast"].To)
+		// The fixture loader created the book.
+		assert.Equal(t, "create", entries[1].Action)
+		assert.Equal(t, "system", entries[1].Actor)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit/book/2", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	entries = nil
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "create", entries[0].Action)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit/shelf/1", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_UntrustedActorHeader(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+	srv := New(s)
+
+	req := httptest.NewRequest(http.MethodPatch, "/books/1", bytes.NewReader([]byte(`{"coast":350}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	req.Header.Set(ActorHeader, "alice")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	entries, err := s.Audit.History("book", 1, 10, 1)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, entries) {
+		assert.Equal(t, "update", entries[0].Action)
+		assert.Equal(t, "system", entries[0].Actor)
+	}
+}
+
+func TestServer_BookHistory(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	// A time between loading the book and patching it.
+	asOf := time.Now().UTC().Format(time.RFC3339Nano)
+
+	req := httptest.NewRequest(http.MethodPatch, "/books/1", bytes.NewReader([]byte(`{"coast":350}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1?as_of="+asOf, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var book struct {
+		Coast uint `json:"coast"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&book))
+	assert.Equal(t, uint(300), book.Coast)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1?as_of=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1/prices", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var periods []struct {
+		Coast         uint       `json:"coast"`
+		EffectiveFrom *time.Time `json:"effective_from"`
+		EffectiveTo   *time.Time `json:"effective_to"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&periods))
+	assert.Equal(t, 2, len(periods))
+	assert.Equal(t, uint(350), periods[1].Coast)
+	assert.Nil(t, periods[1].EffectiveTo)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/99/prices", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_BookPoster(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+	media, err := blob.NewLocalStore(t.TempDir(), "/media")
+	assert.NoError(t, err)
+	srv := New(s).WithPosters(poster.New(s, media))
+
+	var cover bytes.Buffer
+	assert.NoError(t, png.Encode(&cover, image.NewGray(image.Rect(0, 0, 200, 300))))
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	part, err := mw.CreateFormFile("poster", "cover.png")
+	assert.NoError(t, err)
+	_, err = part.Write(cover.Bytes())
+	assert.NoError(t, err)
+	assert.NoError(t, mw.Close())
+
+	testCases := []struct {
+		name        string
+		body        []byte
+		contentType string
+		ifMatch     string
+		status      int
+	}{
+		{name: "missing If-Match", body: cover.Bytes(), status: http.StatusPreconditionRequired},
+		{name: "not an image", body: []byte("hello"), ifMatch: `"1"`, status: http.StatusUnsupportedMediaType},
+		{name: "raw body", body: cover.Bytes(), contentType: "image/png", ifMatch: `"1"`, status: http.StatusOK},
+		{name: "stale version", body: cover.Bytes(), ifMatch: `"1"`, status: http.StatusPreconditionFailed},
+		{name: "multipart", body: form.Bytes(), contentType: mw.FormDataContentType(), ifMatch: `"2"`, status: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/books/1/poster", bytes.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1/poster", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		URL        string            `json:"url"`
+		Thumbnails map[string]string `json:"thumbnails"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Contains(t, body.URL, "/media/posters/1/")
+	assert.Len(t, body.Thumbnails, len(poster.Sizes))
+
+	keys, err := media.List("posters/1/")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1+len(poster.Sizes))
+
+	rec = httptest.NewRecorder()
+	New(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1/poster", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_Health(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn)).WithHealthCheck(func(ctx context.Context) error {
+		return db.HealthCheck(ctx, conn)
+	})
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/readyz", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	// Once the database is gone the server is alive but not ready.
+	assert.NoError(t, conn.Close())
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"unavailable"}`, rec.Body.String())
+}
+
+func TestServer_BookOfDeletedAuthor(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+	srv := New(s)
+	assert.NoError(t, s.Authors.Delete(2))
+
+	req := httptest.NewRequest(http.MethodPatch, "/books/1", bytes.NewReader([]byte(`{"author_id":2}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestServer_AuthorBySlug(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	s := store.NewStore(conn)
+	srv := New(s)
+
+	// The fixture authors were stored without slugs; serve fills them in
+	// at startup.
+	_, err := s.Authors.FillSlugs()
+	assert.NoError(t, err)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/authors/harry-potter", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/authors/nobody", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPatch, "/authors/2", bytes.NewReader([]byte(`{"slug":"harry-potter"}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"taken"`)
+}
+
+func TestServer_ValidationErrors(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	req := httptest.NewRequest(http.MethodPatch, "/authors/1", bytes.NewReader([]byte(`{"last_name":" ","website":"example"}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var body struct {
+		Fields []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"fields"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, 2, len(body.Fields))
+	assert.Equal(t, "last_name", body.Fields[0].Field)
+	assert.Equal(t, "required", body.Fields[0].Code)
+	assert.Equal(t, "website", body.Fields[1].Field)
+	assert.Equal(t, "invalid", body.Fields[1].Code)
+}
+
+func TestServer_BookValidationErrors(t *testing.T) {
+	conn := fixture.NewTestSQLiteDB(t)
+	defer conn.Close()
+	srv := New(store.NewStore(conn))
+
+	body := `{"name":"","release":"2999-01-01T00:00:00Z","coast":0,"pages":150,"author_id":1,"genre_id":1,
+		"editions":[{"format":"paperback","release":"2010-10-10T00:00:00Z","coast":100,"pages":10},{"format":"scroll","release":"2010-10-10T00:00:00Z","coast":100,"pages":10}]}`
+
+	type fieldError struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	testCases := []struct {
+		name     string
+		language string
+		fields   []fieldError
+	}{
+		{
+			name: "english",
+			fields: []fieldError{
+				{Field: "name", Code: "required", Message: "Book name is require field"},
+				{Field: "release", Code: "not_in_past", Message: "Release date must be in past"},
+				{Field: "coast", Code: "required", Message: "Coast is require field"},
+				{Field: "editions[1].format", Code: "invalid", Message: "Edition 2: Format is invalid"},
+			},
+		},
+		{
+			name:     "ukrainian",
+			language: "fr;q=0.9, uk-UA;q=0.8",
+			fields: []fieldError{
+				{Field: "name", Code: "required", Message: "Поле «назва» обов'язкове"},
+				{Field: "release", Code: "not_in_past", Message: "Дата в полі «дата виходу» має бути в минулому"},
+				{Field: "coast", Code: "required", Message: "Поле «ціна» обов'язкове"},
+				{Field: "editions[1].format", Code: "invalid", Message: "Значення поля «формат (видання 2)» некоректне"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/books/1", bytes.NewReader([]byte(body)))
+			req.Header.Set("If-Match", `"1"`)
+			if tc.language != "" {
+				req.Header.Set("Accept-Language", tc.language)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+			var actual struct {
+				Fields []fieldError `json:"fields"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&actual))
+			assert.Equal(t, tc.fields, actual.Fields)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// SystemActor is recorded for writes made without WithActor.
+const SystemActor = "system"
+
+// auditor records writes of the repository it belongs to in audit_log,
+// attributed to actor.
+type auditor struct {
+	actor string
+}
+
+// record stores the fields that differ between before and after. Either may
+// be nil for created and deleted rows. It must run in the write's transaction.
+func (a auditor) record(q querier, entity string, id int64, action string, before, after interface{}) error {
+	diff, err := diffJSON(before, after)
+	if err != nil {
+		return err
+	}
+	if action == models.AuditUpdate && len(diff) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	actor := a.actor
+	if actor == "" {
+		actor = SystemActor
+	}
+
+	_, err = q.Exec(
+		"INSERT INTO audit_log(entity, entity_id, action, actor, created_at, diff) VALUES (?, ?, ?, ?, ?, ?)",
+		entity, id, action, actor, time.Now().UTC(), string(data),
+	)
+	return err
+}
+
+// diffJSON compares the JSON objects of before and after field by field.
+func diffJSON(before, after interface{}) (map[string]models.FieldChange, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	null := json.RawMessage("null")
+	diff := map[string]models.FieldChange{}
+	for name, value := range from {
+		if other, ok := to[name]; !ok || !bytes.Equal(value, other) {
+			change := models.FieldChange{From: value, To: other}
+			if !ok {
+				change.To = null
+			}
+			diff[name] = change
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok {
+			diff[name] = models.FieldChange{From: null, To: value}
+		}
+	}
+	return diff, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// bookSnapshot keeps the stored columns of a book for auditing, dropping
+// joined names, child rows and the version bookkeeping.
+func bookSnapshot(b *models.Book) *models.Book {
+	s := *b
+	s.Release = s.Release.UTC()
+	s.Format = ""
+	s.AuthorName = ""
+	s.GenreName = ""
+	s.Editions = nil
+	s.Series = nil
+	s.Version = 0
+	s.UpdatedAt = time.Time{}
+	s.DeletedAt = nil
+	return &s
+}
+
+func authorSnapshot(a *models.Author) *models.Author {
+	s := *a
+	s.BirthDay = s.BirthDay.UTC()
+	if s.DeathDay != nil {
+		deathDay := s.DeathDay.UTC()
+		s.DeathDay = &deathDay
+	}
+	s.Version = 0
+	s.UpdatedAt = time.Time{}
+	s.DeletedAt = nil
+	return &s
+}
+
+func genreSnapshot(g *models.Genre) *models.Genre {
+	s := *g
+	s.Version = 0
+	s.UpdatedAt = time.Time{}
+	return &s
+}
+
+type AuditRepository struct {
+	db querier
+}
+
+func newAuditRepository(db querier) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// History returns the audit entries of one entity row, newest first.
+func (ar *AuditRepository) History(entity string, id int64, perPage, page int) ([]models.AuditEntry, error) {
+	start := (page - 1) * perPage
+	rows, err := ar.db.Query(
+		`SELECT id, entity, entity_id, action, actor, created_at, diff FROM audit_log
+		WHERE entity = ? AND entity_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		entity, id, perPage, start,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var diff string
+		if err := rows.Scan(&e.Id, &e.Entity, &e.EntityId, &e.Action, &e.Actor, &e.CreatedAt, &diff); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(diff), &e.Diff); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAuditRepository_History(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver()).WithActor("editor@bookland")
+
+	book := &models.Book{
+		Name:      "Audited book",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     250,
+		Pages:     300,
+		PosterURL: "img.png",
+		AuthorId:  1,
+		GenreId:   1,
+	}
+	assert.NoError(t, s.Books.Add(book))
+
+	book.Coast = 275
+	assert.NoError(t, s.Books.Update(book))
+
+	// An update that changes nothing is not recorded.
+	assert.NoError(t, s.Books.Update(book))
+
+	assert.NoError(t, s.Books.Delete(int(book.Id), int(book.AuthorId)))
+
+	entries, err := s.Audit.History("book", book.Id, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(entries))
+
+	deleted, updated, created := entries[0], entries[1], entries[2]
+	assert.Equal(t, models.AuditDelete, deleted.Action)
+	assert.Equal(t, models.AuditUpdate, updated.Action)
+	assert.Equal(t, models.AuditCreate, created.Action)
+
+	for _, e := range entries {
+		assert.Equal(t, "book", e.Entity)
+		assert.Equal(t, book.Id, e.EntityId)
+		assert.Equal(t, "editor@bookland", e.Actor)
+		assert.False(t, e.CreatedAt.IsZero())
+	}
+
+	assert.Equal(t, map[string]models.FieldChange{
+		"coast": {From: json.RawMessage("250"), To: json.RawMessage("275")},
+	}, updated.Diff)
+
+	assert.Equal(t, json.RawMessage("null"), created.Diff["name"].From)
+	assert.Equal(t, json.RawMessage(`"Audited book"`), created.Diff["name"].To)
+	assert.Equal(t, json.RawMessage(`"Audited book"`), deleted.Diff["name"].From)
+	assert.Equal(t, json.RawMessage("null"), deleted.Diff["name"].To)
+
+	entries, err = s.Audit.History("book", book.Id, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestAuditRepository_AuthorsAndGenres(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	bio := "patched bio"
+	_, err := s.Authors.Patch(1, 1, &models.AuthorPatch{Bio: &bio})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Authors.Delete(2))
+
+	genre := &models.Genre{Name: "Fantasy"}
+	assert.NoError(t, s.Genres.Add(genre))
+
+	// The authors were created by the shared test data.
+	entries, err := s.Audit.History("author", 1, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, SystemActor, entries[0].Actor)
+	assert.Equal(t, json.RawMessage(`"patched bio"`), entries[0].Diff["bio"].To)
+
+	entries, err = s.Audit.History("author", 2, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, models.AuditDelete, entries[0].Action)
+
+	entries, err = s.Audit.History("genre", genre.Id, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, models.AuditCreate, entries[0].Action)
+}
+
+func TestAuditRepository_RolledBackWrite(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	book, err := s.Books.GetById(1)
+	assert.NoError(t, err)
+	book.GenreId = 99
+	assert.Error(t, s.Books.Update(book))
+
+	// Only the creation of the book is recorded.
+	entries, err := s.Audit.History("book", 1, 10, 1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, models.AuditCreate, entries[0].Action)
+	}
+}
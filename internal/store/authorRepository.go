@@ -3,108 +3,543 @@ package store
 import (
 	"bookland/internal/models"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 )
 
+const (
+	authorColumns = `id, last_name, first_name, middle_name, pen_name, birthday, death_day, bio,
+	nationality, portrait, website, social_links, COALESCE(slug, ''), version, updated_at, deleted_at`
+	authorSelect = "SELECT " + authorColumns + " FROM author"
+	// authorLive selects the authors that are not in the trash; append conditions with AND.
+	authorLive = authorSelect + " WHERE deleted_at IS NULL"
+
+	// authorNameLike matches the author aliased as a by last or first name,
+	// by both in either order or by pen name. It takes the pattern five times.
+	authorNameLike = `a.last_name LIKE ? OR a.first_name LIKE ? OR a.first_name || ' ' || a.last_name LIKE ?
+	OR a.last_name || ' ' || a.first_name LIKE ? OR a.pen_name LIKE ?`
+	// authorAliasLike selects the first alias of the author aliased as a
+	// that matches the pattern.
+	authorAliasLike = "SELECT al.name FROM author_alias al WHERE al.author_id = a.id AND al.name LIKE ? ORDER BY al.id LIMIT 1"
+)
+
+func scanAuthor(s scanner, a *models.Author, extra ...interface{}) error {
+	return s.Scan(append([]interface{}{
+		&a.Id, &a.LastName, &a.FirstName, &a.MiddleName, &a.PenName, &a.BirthDay, nullTimePtr{&a.DeathDay}, &a.Bio,
+		&a.Nationality, &a.PortraitURL, &a.Website, stringList{&a.SocialLinks}, &a.Slug, &a.Version,
+		nullTime{&a.UpdatedAt}, nullTimePtr{&a.DeletedAt},
+	}, extra...)...)
+}
+
+// uniqueSlug derives a slug from the author's name that no other author
+// uses, appending a number when needed.
+func uniqueSlug(q querier, a *models.Author) (string, error) {
+	base := models.Slugify(a.DisplayName(models.NameFirstLast))
+	if base == "" {
+		base = "author"
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := slugTaken(q, slug, a.Id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// slugTaken reports whether an author other than idAuthor, in the trash or
+// not, has the slug.
+func slugTaken(q querier, slug string, idAuthor int64) (bool, error) {
+	var count int
+	if err := q.QueryRow("SELECT COUNT(id) FROM author WHERE slug = ? AND id <> ?", slug, idAuthor).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkSlug rejects a slug that another author has with
+// models.ValidationErrors, or derives a unique one when slug is empty.
+func checkSlug(q querier, a *models.Author) error {
+	if a.Slug == "" {
+		slug, err := uniqueSlug(q, a)
+		a.Slug = slug
+		return err
+	}
+
+	taken, err := slugTaken(q, a.Slug, a.Id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return models.ValidationErrors{{Field: "slug", Code: models.CodeTaken, Message: "Slug is used by another author"}}
+	}
+	return nil
+}
+
+// repeat returns value n times, for queries that use a parameter repeatedly.
+func repeat(value interface{}, n int) []interface{} {
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
 type AuthorRepository struct {
-	db *sql.DB
+	db    querier
+	audit auditor
 }
 
-func newAuthorRepository(db *sql.DB) *AuthorRepository {
+func newAuthorRepository(db querier) *AuthorRepository {
 	return &AuthorRepository{db: db}
 }
 
+// with returns the repository bound to q, keeping the audit actor.
+func (ar *AuthorRepository) with(q querier) *AuthorRepository {
+	return &AuthorRepository{db: q, audit: ar.audit}
+}
+
 func (ar *AuthorRepository) Get(id int) (*models.Author, error) {
 	author := &models.Author{}
-	row := ar.db.QueryRow("SELECT id, last_name, first_name, birthday, bio FROM author WHERE id = ?", id)
-	err := row.Scan(&author.Id, &author.LastName, &author.FirstName, &author.BirthDay, &author.Bio)
-	if err != nil {
+	if err := scanAuthor(ar.db.QueryRow(authorLive+" AND id = ?", id), author); err != nil {
 		return nil, err
 	}
 	return author, nil
 }
 
+// GetBySlug finds a live author by slug. Authors stored before slugs existed
+// only have one once FillSlugs has run.
+func (ar *AuthorRepository) GetBySlug(slug string) (*models.Author, error) {
+	author := &models.Author{}
+	if err := scanAuthor(ar.db.QueryRow(authorLive+" AND slug = ?", slug), author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// FillSlugs derives a unique slug for every author without one, which are
+// those stored before migration 000014 added the column, and returns how
+// many it filled. The version is kept, as the author did not change. It is
+// meant to run once at startup.
+func (ar *AuthorRepository) FillSlugs() (int, error) {
+	var filled int
+	err := inTx(ar.db, func(q querier) error {
+		rows, err := q.Query(authorSelect + " WHERE slug IS NULL ORDER BY id")
+		if err != nil {
+			return err
+		}
+		var authors []models.Author
+		for rows.Next() {
+			var a models.Author
+			if err := scanAuthor(rows, &a); err != nil {
+				rows.Close()
+				return err
+			}
+			authors = append(authors, a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range authors {
+			slug, err := uniqueSlug(q, &authors[i])
+			if err != nil {
+				return err
+			}
+			if _, err := q.Exec("UPDATE author SET slug = ? WHERE id = ? AND slug IS NULL", slug, authors[i].Id); err != nil {
+				return err
+			}
+		}
+		filled = len(authors)
+		return nil
+	})
+	return filled, err
+}
+
+func (ar *AuthorRepository) GetByName(lastName, firstName string) (*models.Author, error) {
+	author := &models.Author{}
+	row := ar.db.QueryRow(authorLive+" AND last_name = ? AND first_name = ?", lastName, firstName)
+	if err := scanAuthor(row, author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// Add inserts the author, deriving the slug from the name when it is empty.
+// An invalid author, or one with a slug another author has, is rejected with
+// models.ValidationErrors.
 func (ar *AuthorRepository) Add(author *models.Author) error {
-	res, err := ar.db.Exec(
-		"INSERT INTO author(last_name, first_name, birthday, bio) VALUES (?, ?, ?, ?)",
-		author.LastName, author.FirstName, author.BirthDay, author.Bio,
-	)
-	if err != nil {
+	author.Normalize()
+	if err := author.Validate(); err != nil {
 		return err
 	}
-
-	if author.Id, err = res.LastInsertId(); err != nil {
+	links, err := stringListValue(author.SocialLinks)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	now := time.Now().UTC()
+	return inTx(ar.db, func(q querier) error {
+		if err := checkSlug(q, author); err != nil {
+			return err
+		}
+
+		err = q.QueryRow(
+			`INSERT INTO author(last_name, first_name, middle_name, pen_name, birthday, death_day, bio,
+			nationality, portrait, website, social_links, slug, version, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?) RETURNING id`,
+			author.LastName, author.FirstName, author.MiddleName, author.PenName, author.BirthDay, author.DeathDay, author.Bio,
+			author.Nationality, author.PortraitURL, author.Website, links, author.Slug, now,
+		).Scan(&author.Id)
+		if err != nil {
+			return err
+		}
+		author.Version = 1
+		author.UpdatedAt = now
+
+		return ar.audit.record(q, "author", author.Id, models.AuditCreate, nil, authorSnapshot(author))
+	})
 }
 
+// Update saves the author if it still has the version the caller read and
+// returns ErrConflict otherwise. On success author.Version is incremented.
+// An empty slug keeps the stored one; a slug another author has is rejected
+// with models.ValidationErrors.
 func (ar *AuthorRepository) Update(author *models.Author) error {
-	if _, err := ar.db.Exec(
-		"UPDATE author SET last_name = ?, first_name = ?, birthday = ?, bio = ?",
-		author.LastName, author.FirstName, author.BirthDay, author.Bio,
-	); err != nil {
+	author.Normalize()
+	if err := author.Validate(); err != nil {
 		return err
 	}
-	return nil
+	links, err := stringListValue(author.SocialLinks)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return inTx(ar.db, func(q querier) error {
+		before, err := ar.with(q).Get(int(author.Id))
+		if err != nil {
+			return err
+		}
+
+		if author.Slug == "" {
+			author.Slug = before.Slug
+		}
+		if err := checkSlug(q, author); err != nil {
+			return err
+		}
+
+		res, err := q.Exec(
+			`UPDATE author SET last_name = ?, first_name = ?, middle_name = ?, pen_name = ?, birthday = ?, death_day = ?, bio = ?,
+			nationality = ?, portrait = ?, website = ?, social_links = ?, slug = ?, version = version + 1, updated_at = ?
+			WHERE id = ? AND version = ?`,
+			author.LastName, author.FirstName, author.MiddleName, author.PenName, author.BirthDay, author.DeathDay, author.Bio,
+			author.Nationality, author.PortraitURL, author.Website, links, author.Slug, now, author.Id, author.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(q, res, "author", author.Id); err != nil {
+			return err
+		}
+
+		author.Version++
+		author.UpdatedAt = now
+		return ar.audit.record(q, "author", author.Id, models.AuditUpdate, authorSnapshot(before), authorSnapshot(author))
+	})
+}
+
+// Patch applies p to the author read at version and saves it in one
+// transaction. The patched author must pass Validate.
+func (ar *AuthorRepository) Patch(id int, version int64, p *models.AuthorPatch) (*models.Author, error) {
+	var author *models.Author
+	err := inTx(ar.db, func(q querier) error {
+		repo := ar.with(q)
+
+		a, err := repo.Get(id)
+		if err != nil {
+			return err
+		}
+		if a.Version != version {
+			return ErrConflict
+		}
+
+		if err := p.Apply(a); err != nil {
+			return err
+		}
+
+		if err := repo.Update(a); err != nil {
+			return err
+		}
+		author = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return author, nil
 }
 
+// Delete moves the author and all of their books to the trash. The books
+// share the author's deletion time, so Restore brings back exactly these.
 func (ar *AuthorRepository) Delete(id int) error {
-	_, err := ar.db.Exec("DELETE FROM author WHERE id = ?", id)
+	now := time.Now().UTC()
+	return inTx(ar.db, func(q querier) error {
+		before, err := ar.with(q).Get(id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		books, err := newBookRepository(q).query(bookLive+" AND b.author_id = ?", id)
+		if err != nil {
+			return err
+		}
+		if _, err := q.Exec("UPDATE book SET deleted_at = ? WHERE author_id = ? AND deleted_at IS NULL", now, id); err != nil {
+			return err
+		}
+		if err := trashEditions(q, "book_id IN (SELECT id FROM book WHERE author_id = ?)", id); err != nil {
+			return err
+		}
+		for i := range books {
+			if err := closeHistory(q, books[i].Id, now); err != nil {
+				return err
+			}
+			if err := ar.audit.record(q, "book", books[i].Id, models.AuditDelete, bookSnapshot(&books[i]), nil); err != nil {
+				return err
+			}
+		}
+
+		if _, err := q.Exec("UPDATE author SET deleted_at = ? WHERE id = ?", now, id); err != nil {
+			return err
+		}
+		return ar.audit.record(q, "author", before.Id, models.AuditDelete, authorSnapshot(before), nil)
+	})
+}
+
+// Restore takes the author out of the trash together with the books that
+// were deleted along with them. Books deleted on their own stay in the trash.
+// It returns sql.ErrNoRows when the author is not in the trash and ErrISBNInUse
+// when another book has taken the ISBN of one of those books.
+func (ar *AuthorRepository) Restore(id int) error {
+	now := time.Now().UTC()
+	return inTx(ar.db, func(q querier) error {
+		a := &models.Author{}
+		if err := scanAuthor(q.QueryRow(authorSelect+" WHERE id = ? AND deleted_at IS NOT NULL", id), a); err != nil {
+			return err
+		}
+
+		books, err := newBookRepository(q).query(bookSelect+" WHERE b.author_id = ? AND b.deleted_at = a.deleted_at", id)
+		if err != nil {
+			return err
+		}
+		for i := range books {
+			if err := checkRestoredISBNs(q, &books[i]); err != nil {
+				return err
+			}
+		}
+		_, err = q.Exec(
+			"UPDATE book SET deleted_at = NULL WHERE author_id = ? AND deleted_at = (SELECT deleted_at FROM author WHERE id = ?)",
+			id, id,
+		)
+		if err != nil {
+			return err
+		}
+		if err := trashEditions(q, "book_id IN (SELECT id FROM book WHERE author_id = ?)", id); err != nil {
+			return err
+		}
+		if _, err := q.Exec("UPDATE author SET deleted_at = NULL WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		a.DeletedAt = nil
+		if err := ar.audit.record(q, "author", a.Id, models.AuditRestore, nil, authorSnapshot(a)); err != nil {
+			return err
+		}
+		for i := range books {
+			books[i].DeletedAt = nil
+			if err := recordHistory(q, &books[i], now); err != nil {
+				return err
+			}
+			if err := ar.audit.record(q, "book", books[i].Id, models.AuditRestore, nil, bookSnapshot(&books[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetDeleted lists the authors in the trash, most recently deleted first.
+func (ar *AuthorRepository) GetDeleted(perPage int, page int) ([]models.Author, error) {
+	start := (page - 1) * perPage
+	return ar.query(authorSelect+" WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC LIMIT ? OFFSET ?", perPage, start)
+}
+
+// Purge permanently removes the authors that have been in the trash for
+// longer than retention and returns how many were removed. An author is kept
+// while any of their books is live or was deleted within retention, because
+// removing the author would remove those books too.
+func (ar *AuthorRepository) Purge(retention time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-retention)
+	var purged int
+	err := inTx(ar.db, func(q querier) error {
+		ids, err := queryIds(q,
+			`SELECT id FROM author WHERE deleted_at < ? AND NOT EXISTS (
+				SELECT 1 FROM book WHERE book.author_id = author.id AND (book.deleted_at IS NULL OR book.deleted_at >= ?)
+			)`,
+			cutoff, cutoff,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			books, err := queryIds(q, "SELECT id FROM book WHERE author_id = ?", id)
+			if err != nil {
+				return err
+			}
+			for _, idBook := range books {
+				if err := ar.audit.record(q, "book", idBook, models.AuditPurge, nil, nil); err != nil {
+					return err
+				}
+			}
+
+			if _, err := q.Exec("DELETE FROM author WHERE id = ?", id); err != nil {
+				return err
+			}
+			if err := ar.audit.record(q, "author", id, models.AuditPurge, nil, nil); err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+		return nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return err
+	return purged, nil
 }
 
 func (ar *AuthorRepository) Count() (int, error) {
 	var count int
-	if err := ar.db.QueryRow("SELECT COUNT(id) FROM author").Scan(&count); err != nil {
+	if err := ar.db.QueryRow("SELECT COUNT(id) FROM author WHERE deleted_at IS NULL").Scan(&count); err != nil {
 		return 0, nil
 	}
 	return count, nil
 }
 
+// SearchByName matches authors by name, pen name or any of their aliases.
+// Authors found through an alias only have MatchedAlias set to it.
 func (ar *AuthorRepository) SearchByName(value string) ([]models.Author, error) {
 	value = "%" + value + "%"
+	args := append(repeat(value, 6), repeat(value, 6)...)
 	rows, err := ar.db.Query(
-		"SELECT id, last_name, first_name, birthday, bio FROM author WHERE last_name LIKE ? OR first_name LIKE ?",
-		value, value,
+		"SELECT "+authorColumns+", CASE WHEN "+authorNameLike+" THEN NULL ELSE ("+authorAliasLike+") END"+
+			" FROM author a WHERE a.deleted_at IS NULL AND ("+authorNameLike+" OR EXISTS ("+authorAliasLike+"))",
+		args...,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var authors []models.Author
 	for rows.Next() {
 		var a models.Author
-		if err := rows.Scan(&a.Id, &a.LastName, &a.FirstName, &a.BirthDay, &a.Bio); err != nil {
+		var alias sql.NullString
+		if err := scanAuthor(rows, &a, &alias); err != nil {
 			return nil, err
 		}
+		a.MatchedAlias = alias.String
 		authors = append(authors, a)
 	}
+	return authors, rows.Err()
+}
+
+// AddAlias records another name of an author.
+func (ar *AuthorRepository) AddAlias(alias *models.AuthorAlias) error {
+	alias.Name = strings.TrimSpace(alias.Name)
+	if err := alias.Validate(); err != nil {
+		return err
+	}
 
-	return authors, nil
+	return inTx(ar.db, func(q querier) error {
+		err := q.QueryRow(
+			"INSERT INTO author_alias(author_id, name) VALUES (?, ?) RETURNING id", alias.AuthorId, alias.Name,
+		).Scan(&alias.Id)
+		if err != nil {
+			return err
+		}
+		return ar.audit.record(q, "author_alias", alias.Id, models.AuditCreate, nil, alias)
+	})
+}
+
+func (ar *AuthorRepository) RemoveAlias(id int) error {
+	return inTx(ar.db, func(q querier) error {
+		var before models.AuthorAlias
+		err := q.QueryRow("SELECT id, author_id, name FROM author_alias WHERE id = ?", id).Scan(&before.Id, &before.AuthorId, &before.Name)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.Exec("DELETE FROM author_alias WHERE id = ?", id); err != nil {
+			return err
+		}
+		return ar.audit.record(q, "author_alias", before.Id, models.AuditDelete, &before, nil)
+	})
+}
+
+// Aliases returns the aliases of an author in the order they were added.
+func (ar *AuthorRepository) Aliases(idAuthor int) ([]models.AuthorAlias, error) {
+	rows, err := ar.db.Query("SELECT id, author_id, name FROM author_alias WHERE author_id = ? ORDER BY id", idAuthor)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []models.AuthorAlias
+	for rows.Next() {
+		var a models.AuthorAlias
+		if err := rows.Scan(&a.Id, &a.AuthorId, &a.Name); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
 }
 
 func (ar *AuthorRepository) GetPerPage(perPage int, page int) ([]models.Author, error) {
 	start := (page - 1) * perPage
-	rows, err := ar.db.Query(
-		"SELECT id, last_name, first_name, birthday, bio FROM author ORDER BY last_name LIMIT ?, ?",
-		start, perPage,
-	)
+	return ar.query(authorLive+" ORDER BY last_name LIMIT ? OFFSET ?", perPage, start)
+}
+
+func (ar *AuthorRepository) query(query string, args ...interface{}) ([]models.Author, error) {
+	rows, err := ar.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var authors []models.Author
 	for rows.Next() {
 		var a models.Author
-		if err := rows.Scan(&a.Id, &a.LastName, &a.FirstName, &a.BirthDay, &a.Bio); err != nil {
+		if err := scanAuthor(rows, &a); err != nil {
 			return nil, err
 		}
 		authors = append(authors, a)
 	}
 
-	return authors, nil
+	return authors, rows.Err()
 }
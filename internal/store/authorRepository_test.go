@@ -3,11 +3,17 @@ package store
 import (
 	"bookland/internal/db"
 	"bookland/internal/models"
+	"database/sql"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 	"time"
 )
 
+// born is a birth date for authors whose birth date does not matter.
+var born = time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func TestAuthorRepository_Get(t *testing.T) {
 	testCases := []struct {
 		name  string
@@ -25,14 +31,13 @@ func TestAuthorRepository_Get(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -53,23 +58,28 @@ func TestAuthorRepository_Add(t *testing.T) {
 		Id:        0,
 		LastName:  "Potter",
 		FirstName: "Harry",
-		BirthDay:  time.Time{},
+		BirthDay:  time.Date(1965, 7, 31, 0, 0, 0, 0, time.UTC),
 		Bio:       "Test Bio",
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	err := ar.Add(author)
 
 	assert.NotZero(t, author.Id)
 	assert.NoError(t, err)
+
+	unknown := &models.Author{LastName: "Potter", FirstName: "James"}
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "birth_day", Code: models.CodeRequired, Message: "Birth date is require field"},
+	}, ar.Add(unknown))
+	assert.Zero(t, unknown.Id)
 }
 
 func TestAuthorRepository_Update(t *testing.T) {
@@ -77,49 +87,149 @@ func TestAuthorRepository_Update(t *testing.T) {
 		Id:        1,
 		LastName:  "Test Author",
 		FirstName: "Test Author",
-		BirthDay:  time.Time{},
+		BirthDay:  time.Date(1965, 7, 31, 0, 0, 0, 0, time.UTC),
 		Bio:       "Test Bio",
+		Version:   1,
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	err := ar.Update(updateAuthor)
 	assert.NoError(t, err)
 	actualAuthor, err := ar.Get(int(updateAuthor.Id))
 	assert.NoError(t, err)
 	assert.Equal(t, updateAuthor, actualAuthor)
+	assert.Equal(t, int64(2), actualAuthor.Version)
+}
+
+func TestAuthorRepository_UpdateConflict(t *testing.T) {
+	conn := NewTestDB(t)
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal()
+		}
+	}()
+	ar := newAuthorRepository(testDB(conn))
+
+	first, err := ar.Get(1)
+	assert.NoError(t, err)
+	second, err := ar.Get(1)
+	assert.NoError(t, err)
+
+	first.Bio = "first editor"
+	assert.NoError(t, ar.Update(first))
+
+	second.Bio = "second editor"
+	assert.Equal(t, ErrConflict, ar.Update(second))
+
+	actual, err := ar.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "first editor", actual.Bio)
+
+	other, err := ar.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Laurence", other.LastName)
+
+	missing := &models.Author{Id: 99, LastName: "Nobody", FirstName: "Known", Version: 1, BirthDay: born}
+	assert.Equal(t, sql.ErrNoRows, ar.Update(missing))
 }
 
 func TestAuthorRepository_Delete(t *testing.T) {
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	err := ar.Delete(1)
 	assert.NoError(t, err)
 }
 
+func TestAuthorRepository_DeleteCascadesToBooks(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	// Book 1 is deleted on its own before the author and must stay deleted
+	// when the author is restored.
+	assert.NoError(t, s.Books.Delete(1, 1))
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, s.Authors.Delete(1))
+
+	_, err := s.Authors.Get(1)
+	assert.Equal(t, sql.ErrNoRows, err)
+	count, err := s.Books.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+	assert.Equal(t, ErrAuthorDeleted, s.Books.Restore(2))
+
+	deleted, err := s.Authors.GetDeleted(10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deleted))
+
+	assert.NoError(t, s.Authors.Restore(1))
+
+	books, err := s.Books.GetByAuthor(1, 20, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, len(books))
+	_, err = s.Books.GetById(1)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestAuthorRepository_Purge(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	assert.NoError(t, s.Authors.Delete(2))
+
+	books, authors, err := s.Purge(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, books)
+	assert.Equal(t, 0, authors)
+
+	// A book restored elsewhere would be lost with its author, so an author
+	// with live books is never purged.
+	_, err = conn.Exec("UPDATE book SET deleted_at = NULL WHERE id = 11")
+	assert.NoError(t, err)
+	purged, err := s.Authors.Purge(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	books, authors, err = s.Purge(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, books)
+	assert.Equal(t, 0, authors)
+
+	// The book is hidden with its author, so it is trashed directly.
+	_, err = conn.Exec("UPDATE book SET deleted_at = (SELECT deleted_at FROM author WHERE id = 2) WHERE id = 11")
+	assert.NoError(t, err)
+	books, authors, err = s.Purge(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, books)
+	assert.Equal(t, 1, authors)
+
+	entries, err := s.Audit.History("author", 2, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuditPurge, entries[0].Action)
+}
+
 func TestAuthorRepository_Count(t *testing.T) {
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	count, err := ar.Count()
 	assert.NoError(t, err)
@@ -130,14 +240,13 @@ func TestAuthorRepository_SearchByName(t *testing.T) {
 	valid := "arry"
 	invalid := "invalid value for search"
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	authors, err := ar.SearchByName(valid)
 	assert.NotNil(t, authors)
@@ -149,14 +258,13 @@ func TestAuthorRepository_SearchByName(t *testing.T) {
 }
 
 func TestAuthorRepository_GetPerPage(t *testing.T) {
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer func() {
 		if err := conn.Close(); err != nil {
 			t.Fatal()
 		}
 	}()
-	defer db.DropTestSQLiteDB(t)
-	ar := newAuthorRepository(conn)
+	ar := newAuthorRepository(testDB(conn))
 
 	authors, err := ar.GetPerPage(10, 1)
 	assert.NotNil(t, authors)
@@ -168,3 +276,234 @@ func TestAuthorRepository_GetPerPage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, len(authors))
 }
+
+func TestAuthorRepository_GetByName(t *testing.T) {
+	conn := NewTestDB(t)
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal()
+		}
+	}()
+	ar := newAuthorRepository(testDB(conn))
+
+	author, err := ar.GetByName("Potter", "Harry")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), author.Id)
+
+	author, err = ar.GetByName("Harry", "Potter")
+	assert.Error(t, err)
+	assert.Nil(t, author)
+}
+
+func TestAuthorRepository_Patch(t *testing.T) {
+	conn := NewTestDB(t)
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal()
+		}
+	}()
+	ar := newAuthorRepository(testDB(conn))
+
+	bio := "patched bio"
+	author, err := ar.Patch(1, 1, &models.AuthorPatch{Bio: &bio})
+	assert.NoError(t, err)
+	assert.Equal(t, "patched bio", author.Bio)
+	assert.Equal(t, "Potter", author.LastName)
+	assert.Equal(t, int64(2), author.Version)
+
+	_, err = ar.Patch(1, 1, &models.AuthorPatch{Bio: &bio})
+	assert.Equal(t, ErrConflict, err)
+
+	empty := ""
+	_, err = ar.Patch(1, 2, &models.AuthorPatch{LastName: &empty})
+	assert.Error(t, err)
+
+	actual, err := ar.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, author, actual)
+}
+
+func TestAuthorRepository_Aliases(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	alias := &models.AuthorAlias{AuthorId: 1, Name: " Robert Galbraith "}
+	assert.NoError(t, s.Authors.AddAlias(alias))
+	assert.Equal(t, "Robert Galbraith", alias.Name)
+	assert.Error(t, s.Authors.AddAlias(&models.AuthorAlias{AuthorId: 1, Name: "Robert Galbraith"}))
+
+	var fieldErrs models.ValidationErrors
+	assert.True(t, errors.As(s.Authors.AddAlias(&models.AuthorAlias{AuthorId: 1, Name: " "}), &fieldErrs))
+
+	authors, err := s.Authors.SearchByName("Galbraith")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(authors))
+	assert.Equal(t, "Potter", authors[0].LastName)
+	assert.Equal(t, "Robert Galbraith", authors[0].MatchedAlias)
+
+	authors, err = s.Authors.SearchByName("Harry Potter")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(authors))
+	assert.Equal(t, "", authors[0].MatchedAlias)
+
+	books, err := s.Books.Search("galbraith")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(books))
+	assert.Equal(t, "Potter Harry", books[0].AuthorName)
+	assert.Equal(t, "Robert Galbraith", books[0].AuthorAlias)
+
+	books, err = s.Books.Search("test book 1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, books)
+	assert.Equal(t, "", books[0].AuthorAlias)
+
+	aliases, err := s.Authors.Aliases(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AuthorAlias{*alias}, aliases)
+
+	assert.NoError(t, s.Authors.RemoveAlias(int(alias.Id)))
+	authors, err = s.Authors.SearchByName("Galbraith")
+	assert.NoError(t, err)
+	assert.Empty(t, authors)
+
+	entries, err := s.Audit.History("author_alias", alias.Id, 10, 1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, models.AuditDelete, entries[0].Action)
+		assert.Equal(t, models.AuditCreate, entries[1].Action)
+		assert.Equal(t, `"Robert Galbraith"`, string(entries[1].Diff["name"].To))
+	}
+}
+
+func TestAuthorRepository_Profile(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	ar := newAuthorRepository(testDB(conn))
+
+	deathDay := time.Date(1973, 9, 2, 0, 0, 0, 0, time.UTC)
+	author := &models.Author{
+		LastName:    "Tolkien",
+		FirstName:   "John",
+		BirthDay:    time.Date(1892, 1, 3, 0, 0, 0, 0, time.UTC),
+		DeathDay:    &deathDay,
+		Nationality: "GB",
+		PortraitURL: "https://example.com/tolkien.jpg",
+		Website:     "https://www.tolkienestate.com",
+		SocialLinks: []string{"https://twitter.com/tolkien", "https://facebook.com/tolkien"},
+	}
+	assert.NoError(t, ar.Add(author))
+	assert.Equal(t, "john-tolkien", author.Slug)
+
+	actual, err := ar.GetBySlug("john-tolkien")
+	assert.NoError(t, err)
+	assert.Equal(t, author.Id, actual.Id)
+	assert.True(t, deathDay.Equal(*actual.DeathDay))
+	assert.Equal(t, author.Nationality, actual.Nationality)
+	assert.Equal(t, author.PortraitURL, actual.PortraitURL)
+	assert.Equal(t, author.Website, actual.Website)
+	assert.Equal(t, author.SocialLinks, actual.SocialLinks)
+
+	namesake := &models.Author{LastName: "Tolkien", FirstName: "John", BirthDay: born}
+	assert.NoError(t, ar.Add(namesake))
+	assert.Equal(t, "john-tolkien-2", namesake.Slug)
+
+	// Updating without a slug keeps the stored one.
+	actual.Slug = ""
+	actual.SocialLinks = nil
+	assert.NoError(t, ar.Update(actual))
+	actual, err = ar.Get(int(author.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, "john-tolkien", actual.Slug)
+	assert.Nil(t, actual.SocialLinks)
+
+	beforeBirth := time.Date(1800, 1, 1, 0, 0, 0, 0, time.UTC)
+	actual.DeathDay = &beforeBirth
+	var fieldErrs models.ValidationErrors
+	assert.True(t, errors.As(ar.Update(actual), &fieldErrs))
+	assert.Equal(t, "death_day", fieldErrs[0].Field)
+	assert.Equal(t, models.CodeOutOfRange, fieldErrs[0].Code)
+}
+
+func TestAuthorRepository_FillSlugs(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	ar := newAuthorRepository(testDB(conn))
+
+	// Authors stored before migration 000014 have no slug; a namesake
+	// taking one of the derived slugs first pushes the old author to the
+	// next number.
+	_, err := conn.Exec("UPDATE author SET slug = NULL")
+	assert.NoError(t, err)
+	namesake := &models.Author{LastName: "Laurence", FirstName: "Freddy", BirthDay: born}
+	assert.NoError(t, ar.Add(namesake))
+	assert.Equal(t, "freddy-laurence", namesake.Slug)
+
+	// Lookups do not write; the slugs are there once FillSlugs has run.
+	actual, err := ar.GetBySlug("harry-potter")
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Nil(t, actual)
+	filled, err := ar.FillSlugs()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, filled)
+
+	actual, err = ar.GetBySlug("harry-potter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), actual.Id)
+	assert.Equal(t, int64(1), actual.Version)
+
+	actual, err = ar.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "freddy-laurence-2", actual.Slug)
+
+	filled, err = ar.FillSlugs()
+	assert.NoError(t, err)
+	assert.Zero(t, filled)
+}
+
+func TestAuthorRepository_SlugTaken(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	ar := newAuthorRepository(testDB(conn))
+
+	first := &models.Author{LastName: "Herbert", FirstName: "Frank", Slug: "frank-herbert", BirthDay: born}
+	assert.NoError(t, ar.Add(first))
+
+	taken := models.ValidationErrors{{Field: "slug", Code: models.CodeTaken, Message: "Slug is used by another author"}}
+	second := &models.Author{LastName: "Herbert", FirstName: "Brian", Slug: "frank-herbert", BirthDay: born}
+	assert.Equal(t, taken, ar.Add(second))
+	second.Slug = ""
+	assert.NoError(t, ar.Add(second))
+	assert.Equal(t, "brian-herbert", second.Slug)
+
+	second.Slug = "frank-herbert"
+	assert.Equal(t, taken, ar.Update(second))
+	first.Bio = "Dune"
+	assert.NoError(t, ar.Update(first))
+	assert.Equal(t, "frank-herbert", first.Slug)
+}
+
+func TestAuthorRepository_Validation(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	ar := newAuthorRepository(testDB(conn))
+
+	author := &models.Author{LastName: "  ", FirstName: " Harry ", BirthDay: born, Bio: strings.Repeat("b", models.MaxBioLength+1)}
+	err := ar.Add(author)
+	assert.Equal(t, models.ValidationErrors{
+		{Field: "last_name", Code: models.CodeRequired, Message: "Last name is require field"},
+		{Field: "bio", Code: models.CodeTooLong, Message: "Bio is too long"},
+	}, err)
+	assert.Zero(t, author.Id)
+	assert.Equal(t, "Harry", author.FirstName)
+
+	count, err := ar.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	empty := ""
+	_, err = ar.Patch(1, 1, &models.AuthorPatch{FirstName: &empty})
+	var fieldErrs models.ValidationErrors
+	assert.True(t, errors.As(err, &fieldErrs))
+	assert.Equal(t, "first_name", fieldErrs[0].Field)
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"database/sql"
+	"time"
+)
+
+// book_history keeps every state of a book row with the period it was valid
+// in. Times are stored in UTC so that they compare correctly as text.
+const bookHistorySelect = `SELECT h.book_id, h.name, COALESCE(h.isbn, ''), h.released, h.coast, h.pages, h.poster, h.author_id,
+	` + authorName + `,
+	h.genre_id, g.name, h.version, h.valid_from, NULL
+	FROM book_history h INNER JOIN author a ON a.id = h.author_id INNER JOIN genre g on h.genre_id = g.id`
+
+// recordHistory ends the book's current history period at and starts a new
+// one holding the values of b. It must run in the write's transaction.
+func recordHistory(q querier, b *models.Book, at time.Time) error {
+	if err := closeHistory(q, b.Id, at); err != nil {
+		return err
+	}
+
+	_, err := q.Exec(
+		`INSERT INTO book_history(book_id, valid_from, version, name, isbn, released, coast, pages, poster, author_id, genre_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		b.Id, at.UTC(), b.Version, b.Name, nullString(b.ISBN), b.Release, b.Coast, b.Pages, b.PosterURL, b.AuthorId, b.GenreId,
+	)
+	return err
+}
+
+// closeHistory ends the book's current history period at, as when it is deleted.
+func closeHistory(q querier, id int64, at time.Time) error {
+	_, err := q.Exec("UPDATE book_history SET valid_to = ? WHERE book_id = ? AND valid_to IS NULL", at.UTC(), id)
+	return err
+}
+
+// GetByIdAsOf returns the book as it was at the given time, with the author
+// and genre names as they are now. Editions and series are not versioned and
+// are left empty. It returns sql.ErrNoRows if the book did not exist or was
+// deleted at that time.
+func (br *bookRepository) GetByIdAsOf(id int, at time.Time) (*models.Book, error) {
+	at = at.UTC()
+	b := &models.Book{}
+	row := br.db.QueryRow(
+		bookHistorySelect+` WHERE h.book_id = ? AND (h.valid_from IS NULL OR h.valid_from <= ?) AND (h.valid_to IS NULL OR h.valid_to > ?)
+		ORDER BY h.id DESC LIMIT 1`,
+		id, at, at,
+	)
+	if err := scanBook(row, b, br.names); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PriceHistory returns the periods of the book's price in chronological
+// order. Consecutive states with the same price are merged into one period;
+// while the book was deleted there is a gap. It returns sql.ErrNoRows for
+// books without history.
+func (br *bookRepository) PriceHistory(id int) ([]models.PricePeriod, error) {
+	rows, err := br.db.Query("SELECT coast, valid_from, valid_to FROM book_history WHERE book_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []models.PricePeriod
+	for rows.Next() {
+		var p models.PricePeriod
+		if err := rows.Scan(&p.Coast, nullTimePtr{&p.EffectiveFrom}, nullTimePtr{&p.EffectiveTo}); err != nil {
+			return nil, err
+		}
+
+		if n := len(periods); n > 0 {
+			last := &periods[n-1]
+			if last.Coast == p.Coast && last.EffectiveTo != nil && p.EffectiveFrom != nil && last.EffectiveTo.Equal(*p.EffectiveFrom) {
+				last.EffectiveTo = p.EffectiveTo
+				continue
+			}
+		}
+		periods = append(periods, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(periods) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return periods, nil
+}
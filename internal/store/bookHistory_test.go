@@ -0,0 +1,72 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBookRepository_GetByIdAsOf(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	before := time.Now()
+	book, err := br.GetById(1)
+	assert.NoError(t, err)
+	book.Coast = 450
+	assert.NoError(t, br.Update(book))
+	afterUpdate := time.Now()
+	assert.NoError(t, br.Delete(1, 1))
+	afterDelete := time.Now()
+
+	old, err := br.GetByIdAsOf(1, before)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(300), old.Coast)
+	assert.Equal(t, int64(1), old.Version)
+
+	updated, err := br.GetByIdAsOf(1, afterUpdate)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(450), updated.Coast)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, "test_genre", updated.GenreName)
+
+	_, err = br.GetByIdAsOf(1, afterDelete)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, err = br.GetByIdAsOf(99, before)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestBookRepository_PriceHistory(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	book, err := br.GetById(1)
+	assert.NoError(t, err)
+
+	// Changing the name keeps the price period, changing the price starts a new one.
+	book.Name = "Renamed"
+	assert.NoError(t, br.Update(book))
+	book.Coast = 450
+	assert.NoError(t, br.Update(book))
+	book.Pages = 200
+	assert.NoError(t, br.Update(book))
+
+	periods, err := br.PriceHistory(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(periods))
+
+	assert.Equal(t, uint(300), periods[0].Coast)
+	assert.NotNil(t, periods[0].EffectiveFrom)
+	assert.NotNil(t, periods[0].EffectiveTo)
+
+	assert.Equal(t, uint(450), periods[1].Coast)
+	assert.True(t, periods[0].EffectiveTo.Equal(*periods[1].EffectiveFrom))
+	assert.Nil(t, periods[1].EffectiveTo)
+
+	_, err = br.PriceHistory(99)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
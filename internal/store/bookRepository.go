@@ -3,65 +3,491 @@ package store
 import (
 	"bookland/internal/models"
 	"database/sql"
+	"strings"
+	"time"
 )
 
+const (
+	// authorName selects the name parts of the author joined as a, separated
+	// by the ASCII unit separator, for authorNameColumn to format.
+	authorName  = "a.last_name || char(31) || a.first_name || char(31) || a.middle_name || char(31) || a.pen_name"
+	bookColumns = `b.id, b.name, COALESCE(b.isbn, ''), b.released, b.coast, b.pages, b.poster, b.author_id,
+	` + authorName + `,
+	b.genre_id, g.name, b.version, b.updated_at, b.deleted_at`
+	bookFrom   = "FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id"
+	bookSelect = "SELECT " + bookColumns + " " + bookFrom
+	// bookLive selects the books that are not in the trash and whose author
+	// is not either; append conditions with AND.
+	bookLive      = bookSelect + bookLiveWhere
+	bookLiveWhere = " WHERE b.deleted_at IS NULL AND a.deleted_at IS NULL"
+)
+
+// authorNameColumn scans the authorName column into a display name in format.
+type authorNameColumn struct {
+	name   *string
+	format models.NameFormat
+}
+
+func (c authorNameColumn) Scan(value interface{}) error {
+	var s sql.NullString
+	if err := s.Scan(value); err != nil {
+		return err
+	}
+
+	parts := strings.Split(s.String, "\x1f")
+	for len(parts) < 4 {
+		parts = append(parts, "")
+	}
+	*c.name = models.FormatName(c.format, parts[0], parts[1], parts[2], parts[3])
+	return nil
+}
+
+// bookFields returns the scan destinations matching bookColumns, rendering
+// the author name in format.
+func bookFields(b *models.Book, format models.NameFormat) []interface{} {
+	return []interface{}{
+		&b.Id, &b.Name, &b.ISBN, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, authorNameColumn{&b.AuthorName, format},
+		&b.GenreId, &b.GenreName, &b.Version, nullTime{&b.UpdatedAt}, nullTimePtr{&b.DeletedAt},
+	}
+}
+
+func scanBook(s scanner, b *models.Book, format models.NameFormat) error {
+	return s.Scan(bookFields(b, format)...)
+}
+
+// primaryEdition returns the edition described by the book's own format,
+// ISBN, release date, price, pages and poster. A book without a format
+// describes an unspecified one.
+func primaryEdition(b *models.Book) models.Edition {
+	format := b.Format
+	if format == "" {
+		format = models.FormatUnspecified
+	}
+	return models.Edition{
+		BookId:    b.Id,
+		Format:    format,
+		ISBN:      b.ISBN,
+		Release:   b.Release,
+		Coast:     b.Coast,
+		Pages:     b.Pages,
+		PosterURL: b.PosterURL,
+	}
+}
+
+// copyEdition sets the book's format, ISBN, release date, price, pages and
+// poster from its primary edition e.
+func copyEdition(b *models.Book, e *models.Edition) {
+	b.Format = e.Format
+	b.ISBN = e.ISBN
+	b.Release = e.Release
+	b.Coast = e.Coast
+	b.Pages = e.Pages
+	b.PosterURL = e.PosterURL
+}
+
+// checkAuthor returns ErrAuthorDeleted when the author is in the trash. A
+// missing author is left to the foreign key.
+func checkAuthor(q querier, idAuthor int64) error {
+	var deleted bool
+	err := q.QueryRow("SELECT deleted_at IS NOT NULL FROM author WHERE id = ?", idAuthor).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return ErrAuthorDeleted
+	}
+	return nil
+}
+
+// checkISBN returns ErrISBNInUse when a book outside the trash other than
+// idBook, or an edition of any book outside the trash other than idEdition,
+// has the ISBN. The book's own ISBN is its primary edition's, so only the
+// editions are compared within idBook. Books in the trash keep their ISBN,
+// so it is only taken by the book that gets it first.
+func checkISBN(q querier, isbn string, idBook int64, idEdition int64) error {
+	if isbn == "" {
+		return nil
+	}
+
+	var count int
+	err := q.QueryRow(
+		`SELECT COUNT(b.id) FROM book b WHERE b.deleted_at IS NULL AND ((b.id <> ? AND b.isbn = ?)
+		OR EXISTS (SELECT 1 FROM edition e WHERE e.book_id = b.id AND e.isbn = ? AND e.id <> ?))`,
+		idBook, isbn, isbn, idEdition,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrISBNInUse
+	}
+	return nil
+}
+
+// checkRestoredISBNs returns ErrISBNInUse when a book outside the trash has
+// taken the ISBN of the trashed book b or one of its editions.
+func checkRestoredISBNs(q querier, b *models.Book) error {
+	if err := checkISBN(q, b.ISBN, b.Id, 0); err != nil {
+		return err
+	}
+	editions, err := newEditionRepository(q).GetByBook(int(b.Id))
+	if err != nil {
+		return err
+	}
+	for _, e := range editions {
+		if err := checkISBN(q, e.ISBN, b.Id, e.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullString maps an empty string to NULL, so optional unique columns
+// such as isbn do not collide on empty values.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 type bookRepository struct {
-	db *sql.DB
+	db    querier
+	audit auditor
+	names models.NameFormat
 }
 
-func newBookRepository(db *sql.DB) *bookRepository {
+func newBookRepository(db querier) *bookRepository {
 	return &bookRepository{db: db}
 }
 
+// with returns the repository bound to q, keeping the audit actor.
+func (br *bookRepository) with(q querier) *bookRepository {
+	return &bookRepository{db: q, audit: br.audit, names: br.names}
+}
+
+func (br *bookRepository) getRow(id int64) (*models.Book, error) {
+	b := &models.Book{}
+	if err := scanBook(br.db.QueryRow(bookLive+" AND b.id = ?", id), b, br.names); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add inserts the book together with its editions. The first edition is the
+// primary one: the book's ISBN, release date, price, pages and poster are
+// taken from it, and it is created from them when b has no editions. An
+// invalid book is rejected with models.ValidationErrors.
 func (br *bookRepository) Add(b *models.Book) error {
-	res, err := br.db.Exec(
-		"INSERT INTO book(name, released, coast, pages, poster, author_id, genre_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		b.Name, b.Release, b.Coast, b.Pages, b.PosterURL, b.AuthorId, b.GenreId,
-	)
-	if err != nil {
+	if len(b.Editions) > 0 {
+		copyEdition(b, &b.Editions[0])
+	}
+	if err := b.Validate(); err != nil {
 		return err
 	}
-	if b.Id, err = res.LastInsertId(); err != nil {
+
+	isbn, err := models.NormalizeISBN(b.ISBN)
+	if err != nil {
 		return err
 	}
-	return nil
+	b.ISBN = isbn
+
+	now := time.Now().UTC()
+	return inTx(br.db, func(q querier) error {
+		if err := checkAuthor(q, b.AuthorId); err != nil {
+			return err
+		}
+		if err := checkISBN(q, b.ISBN, 0, 0); err != nil {
+			return err
+		}
+
+		err := q.QueryRow(
+			`INSERT INTO book(name, isbn, released, coast, pages, poster, author_id, genre_id, version, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?) RETURNING id`,
+			b.Name, nullString(b.ISBN), b.Release, b.Coast, b.Pages, b.PosterURL, b.AuthorId, b.GenreId, now,
+		).Scan(&b.Id)
+		if err != nil {
+			return err
+		}
+		b.Version = 1
+		b.UpdatedAt = now
+
+		if len(b.Editions) == 0 {
+			b.Editions = []models.Edition{primaryEdition(b)}
+			b.Format = b.Editions[0].Format
+		}
+		er := newEditionRepository(q)
+		for i := range b.Editions {
+			b.Editions[i].BookId = b.Id
+			if err := er.insert(&b.Editions[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := recordHistory(q, b, now); err != nil {
+			return err
+		}
+		return br.audit.record(q, "book", b.Id, models.AuditCreate, nil, bookSnapshot(b))
+	})
 }
 
+// GetById returns the book with all of its editions and the series it belongs to.
 func (br *bookRepository) GetById(id int) (*models.Book, error) {
-	b := &models.Book{}
+	b, err := br.getRow(int64(id))
+	if err != nil {
+		return nil, err
+	}
 
-	err := br.db.QueryRow(
-		"SELECT b.id, b.name, b.released, b.coast, b.pages, b.poster, b.author_id, a.last_name + ' ' + a.first_name,  b.genre_id, g.name FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id WHERE b.id = ?", id,
-	).Scan(&b.Id, &b.Name, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, &b.AuthorName, &b.GenreId, &b.GenreName)
+	editions, err := newEditionRepository(br.db).GetByBook(id)
+	if err != nil {
+		return nil, err
+	}
+	b.Editions = editions
+	var primary int64
+	for _, e := range editions {
+		if primary == 0 || e.Id < primary {
+			primary, b.Format = e.Id, e.Format
+		}
+	}
 
+	series, err := newSeriesRepository(br.db).GetByBook(id)
 	if err != nil {
 		return nil, err
 	}
+	b.Series = series
 
 	return b, nil
 }
 
-func (br *bookRepository) Update(b *models.Book) error {
-	_, err := br.db.Exec(
-		"UPDATE book SET name = ?, poster = ?, coast = ?, pages = ?, released = ?, author_id = ?, genre_id = ? WHERE id = ?",
-		b.Name, b.PosterURL, b.Coast, b.Pages, b.Release, b.AuthorId, b.GenreId, b.Id,
-	)
+// GetByISBN finds a book by the ISBN-10 or ISBN-13, with or without hyphens,
+// of any of its editions.
+func (br *bookRepository) GetByISBN(isbn string) (*models.Book, error) {
+	isbn, err := models.NormalizeISBN(isbn)
 	if err != nil {
+		return nil, err
+	}
+	if isbn == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	b := &models.Book{}
+	row := br.db.QueryRow(
+		bookLive+" AND (b.isbn = ? OR EXISTS (SELECT 1 FROM edition e WHERE e.book_id = b.id AND e.isbn = ?))",
+		isbn, isbn,
+	)
+	if err := scanBook(row, b, br.names); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Update saves the book if it still has the version the caller read and
+// returns ErrConflict otherwise. On success b.Version is incremented. The
+// primary edition is kept in step with the book; b.Editions is ignored and
+// a book without a format keeps its primary edition's.
+func (br *bookRepository) Update(b *models.Book) error {
+	now := time.Now().UTC()
+	return inTx(br.db, func(q querier) error {
+		before, err := br.with(q).getRow(b.Id)
+		if err != nil {
+			return err
+		}
+
+		var idPrimary int64
+		primary, err := newEditionRepository(q).primary(b.Id)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if primary != nil {
+			idPrimary = primary.Id
+			if b.Format == "" {
+				b.Format = primary.Format
+			}
+		}
+
+		if err := b.Validate(); err != nil {
+			return err
+		}
+		isbn, err := models.NormalizeISBN(b.ISBN)
+		if err != nil {
+			return err
+		}
+		b.ISBN = isbn
+
+		if err := checkAuthor(q, b.AuthorId); err != nil {
+			return err
+		}
+		if err := checkISBN(q, b.ISBN, b.Id, idPrimary); err != nil {
+			return err
+		}
+
+		res, err := q.Exec(
+			`UPDATE book SET name = ?, isbn = ?, poster = ?, coast = ?, pages = ?, released = ?, author_id = ?, genre_id = ?,
+			version = version + 1, updated_at = ?
+			WHERE id = ? AND version = ?`,
+			b.Name, nullString(b.ISBN), b.PosterURL, b.Coast, b.Pages, b.Release, b.AuthorId, b.GenreId, now, b.Id, b.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(q, res, "book", b.Id); err != nil {
+			return err
+		}
+
+		b.Version++
+		b.UpdatedAt = now
+		if err := newEditionRepository(q).syncPrimary(b); err != nil {
+			return err
+		}
+		if err := recordHistory(q, b, now); err != nil {
+			return err
+		}
+		return br.audit.record(q, "book", b.Id, models.AuditUpdate, bookSnapshot(before), bookSnapshot(b))
+	})
+}
+
+// Patch applies p to the book read at version and saves it in one transaction.
+// The patch and the patched book must be valid, otherwise their
+// models.ValidationErrors are returned and nothing is written.
+func (br *bookRepository) Patch(id int, version int64, p *models.BookPatch) (*models.Book, error) {
+	var book *models.Book
+	err := inTx(br.db, func(q querier) error {
+		repo := br.with(q)
+
+		b, err := repo.GetById(id)
+		if err != nil {
+			return err
+		}
+		if b.Version != version {
+			return ErrConflict
+		}
+
+		if err := p.Apply(b); err != nil {
+			return err
+		}
+		if err := b.Validate(); err != nil {
+			return err
+		}
+
+		if err := repo.Update(b); err != nil {
+			return err
+		}
+
+		book, err = repo.GetById(id)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return book, nil
 }
 
+// Delete moves the book to the trash. It stays out of listings until it is
+// restored or purged.
 func (br *bookRepository) Delete(id int, idAuthor int) error {
-	if _, err := br.db.Exec("DELETE FROM book WHERE id = ? AND author_id = ?", id, idAuthor); err != nil {
-		return err
+	now := time.Now().UTC()
+	return inTx(br.db, func(q querier) error {
+		before, err := br.with(q).getRow(int64(id))
+		if err == sql.ErrNoRows || (err == nil && before.AuthorId != int64(idAuthor)) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.Exec("UPDATE book SET deleted_at = ? WHERE id = ? AND author_id = ?", now, id, idAuthor); err != nil {
+			return err
+		}
+		if err := trashEditions(q, "book_id = ?", id); err != nil {
+			return err
+		}
+		if err := closeHistory(q, before.Id, now); err != nil {
+			return err
+		}
+		return br.audit.record(q, "book", before.Id, models.AuditDelete, bookSnapshot(before), nil)
+	})
+}
+
+// Restore takes the book out of the trash. It returns sql.ErrNoRows when the
+// book is not in the trash, ErrAuthorDeleted while its author is and
+// ErrISBNInUse when another book has taken its ISBN or an edition's.
+func (br *bookRepository) Restore(id int) error {
+	now := time.Now().UTC()
+	return inTx(br.db, func(q querier) error {
+		b := &models.Book{}
+		row := q.QueryRow(bookSelect+" WHERE b.id = ? AND b.deleted_at IS NOT NULL", id)
+		if err := scanBook(row, b, br.names); err != nil {
+			return err
+		}
+
+		if err := checkAuthor(q, b.AuthorId); err != nil {
+			return err
+		}
+		if err := checkRestoredISBNs(q, b); err != nil {
+			return err
+		}
+
+		if _, err := q.Exec("UPDATE book SET deleted_at = NULL WHERE id = ?", id); err != nil {
+			return err
+		}
+		if err := trashEditions(q, "book_id = ?", id); err != nil {
+			return err
+		}
+		b.DeletedAt = nil
+		if err := recordHistory(q, b, now); err != nil {
+			return err
+		}
+		return br.audit.record(q, "book", b.Id, models.AuditRestore, nil, bookSnapshot(b))
+	})
+}
+
+// GetDeleted lists the books in the trash, most recently deleted first.
+func (br *bookRepository) GetDeleted(perPage int, page int) ([]models.Book, error) {
+	start := (page - 1) * perPage
+	return br.query(bookSelect+" WHERE b.deleted_at IS NOT NULL ORDER BY b.deleted_at DESC, b.id DESC LIMIT ? OFFSET ?", perPage, start)
+}
+
+// Purge permanently removes the books that have been in the trash for longer
+// than retention, together with their editions, and returns how many were removed.
+func (br *bookRepository) Purge(retention time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-retention)
+	var purged int
+	err := inTx(br.db, func(q querier) error {
+		ids, err := queryIds(q, "SELECT id FROM book WHERE deleted_at < ?", cutoff)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if _, err := q.Exec("DELETE FROM book WHERE id = ?", id); err != nil {
+				return err
+			}
+			if err := br.audit.record(q, "book", id, models.AuditPurge, nil, nil); err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return purged, nil
+}
+
+// Poster returns the poster URL stored for the book, including books in the
+// trash, or sql.ErrNoRows once the book is purged.
+func (br *bookRepository) Poster(id int) (string, error) {
+	var poster string
+	if err := br.db.QueryRow("SELECT poster FROM book WHERE id = ?", id).Scan(&poster); err != nil {
+		return "", err
+	}
+	return poster, nil
 }
 
 func (br *bookRepository) Count() (int, error) {
 	var count int
-	if err := br.db.QueryRow("SELECT COUNT(id) FROM book").Scan(&count); err != nil {
+	if err := br.db.QueryRow("SELECT COUNT(b.id) " + bookFrom + bookLiveWhere).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -69,93 +495,96 @@ func (br *bookRepository) Count() (int, error) {
 
 func (br *bookRepository) GetPerPage(perPage int, page int) ([]models.Book, error) {
 	start := (page - 1) * perPage
-	rows, err := br.db.Query(
-		"SELECT b.id, b.name, b.released, b.coast, b.pages, b.poster, b.author_id, a.last_name + ' ' + a.first_name,  b.genre_id, g.name FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id ORDER BY b.id DESC LIMIT ?, ?",
-		start, perPage,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	var books []models.Book
-	for rows.Next() {
-		var b models.Book
-		err := rows.Scan(&b.Id, &b.Name, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, &b.AuthorName, &b.GenreId, &b.GenreName)
-		if err != nil {
-			return nil, err
-		}
-		books = append(books, b)
-	}
-	return books, nil
+	return br.query(bookLive+" ORDER BY b.id DESC LIMIT ? OFFSET ?", perPage, start)
 }
 
 func (br *bookRepository) GetByGenre(idGenre, perPage, page int) ([]models.Book, error) {
 	start := (page - 1) * perPage
+	return br.query(bookLive+" AND b.genre_id = ? ORDER BY b.id DESC LIMIT ? OFFSET ?", idGenre, perPage, start)
+}
+
+func (br *bookRepository) GetByAuthor(idAuthor, perPage, page int) ([]models.Book, error) {
+	start := (page - 1) * perPage
+	return br.query(bookLive+" AND b.author_id = ? ORDER BY b.id DESC LIMIT ? OFFSET ?", idAuthor, perPage, start)
+}
+
+// Search matches books by name, genre or author. Authors match by name
+// written either first name first or last name first, by pen name or by any
+// of their aliases. Books found through an alias only have AuthorAlias set to it.
+func (br *bookRepository) Search(value string) ([]models.Book, error) {
+	value = "%" + value + "%"
+	match := "b.name LIKE ? OR g.name LIKE ? OR " + authorNameLike
 	rows, err := br.db.Query(
-		"SELECT b.id, b.name, b.released, b.coast, b.pages, b.poster, b.author_id, a.last_name + ' ' + a.first_name,  b.genre_id, g.name FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id WHERE genre_id = ? ORDER BY b.id DESC LIMIT ?, ?",
-		idGenre, start, perPage,
+		"SELECT "+bookColumns+", CASE WHEN "+match+" THEN NULL ELSE ("+authorAliasLike+") END "+bookFrom+
+			bookLiveWhere+" AND ("+match+" OR EXISTS ("+authorAliasLike+"))",
+		append(repeat(value, 8), repeat(value, 8)...)...,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var books []models.Book
 	for rows.Next() {
 		var b models.Book
-		err := rows.Scan(&b.Id, &b.Name, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, &b.AuthorName, &b.GenreId, &b.GenreName)
-		if err != nil {
+		var alias sql.NullString
+		if err := rows.Scan(append(bookFields(&b, br.names), &alias)...); err != nil {
 			return nil, err
 		}
+		b.AuthorAlias = alias.String
 		books = append(books, b)
 	}
-	return books, nil
+	return books, rows.Err()
 }
 
-func (br *bookRepository) GetByAuthor(idAuthor, perPage, page int) ([]models.Book, error) {
-	start := (page - 1) * perPage
+// ForEach streams every book not in the trash ordered by id to fn without loading the whole
+// table into memory. Iteration stops at the first error returned by fn.
+func (br *bookRepository) ForEach(fn func(b *models.Book) error) error {
+	return br.ForEachWithAuthor(func(b *models.Book, _ *models.Author) error {
+		return fn(b)
+	})
+}
+
+// ForEachWithAuthor streams the books as ForEach does, together with the
+// name of each book's author read in the same query. Only the Id, LastName,
+// FirstName, MiddleName and PenName of the author are filled in.
+func (br *bookRepository) ForEachWithAuthor(fn func(b *models.Book, a *models.Author) error) error {
 	rows, err := br.db.Query(
-		"SELECT b.id, b.name, b.released, b.coast, b.pages, b.poster, b.author_id, a.last_name + ' ' + a.first_name,  b.genre_id, g.name FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id WHERE author_id = ? ORDER BY b.id DESC LIMIT ?, ?",
-		idAuthor, start, perPage,
-	)
+		"SELECT " + bookColumns + ", a.last_name, a.first_name, a.middle_name, a.pen_name " + bookFrom + bookLiveWhere + " ORDER BY b.id")
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rows.Close()
 
-	var books []models.Book
 	for rows.Next() {
 		var b models.Book
-		err := rows.Scan(&b.Id, &b.Name, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, &b.AuthorName, &b.GenreId, &b.GenreName)
-		if err != nil {
-			return nil, err
+		var a models.Author
+		fields := append(bookFields(&b, br.names), &a.LastName, &a.FirstName, &a.MiddleName, &a.PenName)
+		if err := rows.Scan(fields...); err != nil {
+			return err
+		}
+		a.Id = b.AuthorId
+		if err := fn(&b, &a); err != nil {
+			return err
 		}
-		books = append(books, b)
 	}
-	return books, nil
+	return rows.Err()
 }
 
-func (br *bookRepository) Search(value string) ([]models.Book, error) {
-	value = "%" + value + "%"
-	rows, err := br.db.Query(
-		`SELECT b.id, b.name, b.released, b.coast, b.pages, b.poster, b.author_id, 
-    	a.last_name + ' ' + a.first_name,  
-    	b.genre_id, g.name 
-		FROM book b INNER JOIN author a ON a.id = b.author_id INNER JOIN genre g on b.genre_id = g.id 
-		WHERE b.name LIKE ? OR g.name LIKE ? OR a.first_name LIKE ? OR a.first_name = ?`,
-		value, value, value, value,
-	)
+func (br *bookRepository) query(query string, args ...interface{}) ([]models.Book, error) {
+	rows, err := br.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var books []models.Book
 	for rows.Next() {
 		var b models.Book
-		if err := rows.Scan(
-			&b.Id, &b.Name, &b.Release, &b.Coast, &b.Pages, &b.PosterURL, &b.AuthorId, &b.AuthorName, &b.GenreId, &b.GenreName,
-		); err != nil {
+		if err := scanBook(rows, &b, br.names); err != nil {
 			return nil, err
 		}
 		books = append(books, b)
 	}
-	return books, nil
+	return books, rows.Err()
 }
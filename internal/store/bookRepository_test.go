@@ -3,6 +3,8 @@ package store
 import (
 	"bookland/internal/db"
 	"bookland/internal/models"
+	"database/sql"
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -60,10 +62,9 @@ func TestBookRepository_Add(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -98,10 +99,9 @@ func TestBookRepository_GetById(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -127,6 +127,7 @@ func TestBookRepository_Update(t *testing.T) {
 		PosterURL: "img.png2",
 		AuthorId:  1,
 		GenreId:   1,
+		Version:   1,
 	}
 
 	incorrectBook := &models.Book{
@@ -138,6 +139,7 @@ func TestBookRepository_Update(t *testing.T) {
 		PosterURL: "",
 		AuthorId:  256,
 		GenreId:   234,
+		Version:   2,
 	}
 
 	testCases := []struct {
@@ -157,10 +159,9 @@ func TestBookRepository_Update(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -176,6 +177,34 @@ func TestBookRepository_Update(t *testing.T) {
 	return
 }
 
+func TestBookRepository_UpdateConflict(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	first, err := br.GetById(1)
+	assert.NoError(t, err)
+	second, err := br.GetById(1)
+	assert.NoError(t, err)
+
+	first.Coast = 400
+	assert.NoError(t, br.Update(first))
+	assert.Equal(t, int64(2), first.Version)
+
+	second.Coast = 500
+	assert.Equal(t, ErrConflict, br.Update(second))
+
+	actual, err := br.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(400), actual.Coast)
+	assert.Equal(t, int64(2), actual.Version)
+	assert.False(t, actual.UpdatedAt.IsZero())
+
+	missing := *actual
+	missing.Id = 99
+	assert.Equal(t, sql.ErrNoRows, br.Update(&missing))
+}
+
 func TestBookRepository_Delete(t *testing.T) {
 	testCase := []struct {
 		name     string
@@ -209,10 +238,9 @@ func TestBookRepository_Delete(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCase {
 		t.Run(tc.name, func(t *testing.T) {
@@ -234,11 +262,60 @@ func TestBookRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestBookRepository_Restore(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	assert.NoError(t, br.Delete(1, 1))
+
+	deleted, err := br.GetDeleted(10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deleted))
+	assert.Equal(t, int64(1), deleted[0].Id)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	books, err := br.GetByAuthor(1, 20, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, len(books))
+
+	assert.NoError(t, br.Restore(1))
+	assert.Equal(t, sql.ErrNoRows, br.Restore(1))
+
+	b, err := br.GetById(1)
+	assert.NoError(t, err)
+	assert.Nil(t, b.DeletedAt)
+
+	count, err := br.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 16, count)
+}
+
+func TestBookRepository_Purge(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	assert.NoError(t, br.Delete(1, 1))
+
+	purged, err := br.Purge(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = br.Purge(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, sql.ErrNoRows, br.Restore(1))
+
+	deleted, err := br.GetDeleted(10, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+}
+
 func TestBookRepository_Count(t *testing.T) {
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	count, err := br.Count()
 
@@ -273,10 +350,9 @@ func TestBookRepository_GetPerPage(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -311,10 +387,9 @@ func TestBookRepository_GetByGenre(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -349,10 +424,9 @@ func TestBookRepository_GetByAuthor(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -389,6 +463,16 @@ func TestBookRepository_SearchByName(t *testing.T) {
 			searchVal: "Harry",
 			found:     true,
 		},
+		{
+			name:      "search by full author name",
+			searchVal: "Harry Potter",
+			found:     true,
+		},
+		{
+			name:      "search by author name last name first",
+			searchVal: "Potter Harry",
+			found:     true,
+		},
 		{
 			name:      "search by genre name",
 			searchVal: "test_genre 2",
@@ -396,10 +480,9 @@ func TestBookRepository_SearchByName(t *testing.T) {
 		},
 	}
 
-	conn := db.NewTestSQLiteDB(t)
+	conn := NewTestDB(t)
 	defer conn.Close()
-	defer db.DropTestSQLiteDB(t)
-	br := newBookRepository(conn)
+	br := newBookRepository(testDB(conn))
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -414,3 +497,281 @@ func TestBookRepository_SearchByName(t *testing.T) {
 		})
 	}
 }
+
+func TestBookRepository_ForEach(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	var ids []int64
+	err := br.ForEach(func(b *models.Book) error {
+		ids = append(ids, b.Id)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 16, len(ids))
+	assert.Equal(t, int64(1), ids[0])
+
+	stop := errors.New("stop")
+	calls := 0
+	err = br.ForEach(func(b *models.Book) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	authors := map[int64]string{}
+	err = br.ForEachWithAuthor(func(b *models.Book, a *models.Author) error {
+		assert.Equal(t, b.AuthorId, a.Id)
+		authors[a.Id] = a.LastName + " " + a.FirstName
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]string{1: "Potter Harry", 2: "Laurence Freddy"}, authors)
+}
+
+func TestBookRepository_GetByISBN(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	book := &models.Book{
+		Name:      "Book with ISBN",
+		ISBN:      "0-306-40615-2",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     250,
+		Pages:     300,
+		PosterURL: "img.png",
+		AuthorId:  1,
+		GenreId:   1,
+	}
+	assert.NoError(t, br.Add(book))
+	assert.Equal(t, "9780306406157", book.ISBN)
+
+	testCases := []struct {
+		name  string
+		isbn  string
+		found bool
+	}{
+		{
+			name:  "isbn-13",
+			isbn:  "9780306406157",
+			found: true,
+		},
+		{
+			name:  "isbn-13 with hyphens",
+			isbn:  "978-0-306-40615-7",
+			found: true,
+		},
+		{
+			name:  "isbn-10",
+			isbn:  "0306406152",
+			found: true,
+		},
+		{
+			name:  "unknown isbn",
+			isbn:  "9780804429573",
+			found: false,
+		},
+		{
+			name:  "invalid isbn",
+			isbn:  "9780306406158",
+			found: false,
+		},
+		{
+			name:  "empty isbn",
+			isbn:  "",
+			found: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := br.GetByISBN(tc.isbn)
+			if tc.found {
+				assert.NoError(t, err)
+				assert.Equal(t, book.Id, b.Id)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, b)
+			}
+		})
+	}
+}
+
+func TestBookRepository_UniqueISBN(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	newBook := func(isbn string) *models.Book {
+		return &models.Book{
+			Name:      "Book",
+			ISBN:      isbn,
+			Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+			Coast:     250,
+			Pages:     300,
+			PosterURL: "img.png",
+			AuthorId:  1,
+			GenreId:   1,
+		}
+	}
+
+	first := newBook("9780306406157")
+	assert.NoError(t, br.Add(first))
+	assert.Equal(t, ErrISBNInUse, br.Add(newBook("0-306-40615-2")))
+	assert.NoError(t, br.Add(newBook("")))
+	assert.NoError(t, br.Add(newBook("")))
+
+	// A book in the trash gives up its ISBN until it is restored.
+	assert.NoError(t, br.Delete(int(first.Id), 1))
+	second := newBook("0-306-40615-2")
+	assert.NoError(t, br.Add(second))
+	assert.Equal(t, ErrISBNInUse, br.Restore(int(first.Id)))
+
+	assert.NoError(t, br.Delete(int(second.Id), 1))
+	assert.NoError(t, br.Restore(int(first.Id)))
+
+	// The database keeps ISBNs unique among live editions on its own, in
+	// case two writers pass the check at once.
+	_, err := conn.Exec(`INSERT INTO edition(book_id, format, isbn, released, coast, pages, poster)
+		VALUES (2, 'ebook', '9780306406157', '2010-10-10', 100, 0, 'img.png')`)
+	assert.Error(t, err)
+
+	// Restoring an author checks the ISBNs of the books coming back.
+	ar := newAuthorRepository(testDB(conn))
+	assert.NoError(t, ar.Delete(1))
+	assert.NoError(t, br.Add(&models.Book{
+		Name:      "Book",
+		ISBN:      "9780306406157",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     250,
+		Pages:     300,
+		PosterURL: "img.png",
+		AuthorId:  2,
+		GenreId:   1,
+	}))
+	assert.Equal(t, ErrISBNInUse, ar.Restore(1))
+}
+
+func TestBookRepository_DeletedAuthor(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	book, err := s.Books.GetById(1)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Authors.Delete(2))
+
+	book.AuthorId = 2
+	assert.Equal(t, ErrAuthorDeleted, s.Books.Update(book))
+	book.Id = 0
+	assert.Equal(t, ErrAuthorDeleted, s.Books.Add(book))
+
+	// A book left live under a trashed author is hidden with the author.
+	_, err = conn.Exec("UPDATE book SET deleted_at = NULL WHERE id = 11")
+	assert.NoError(t, err)
+	_, err = s.Books.GetById(11)
+	assert.Equal(t, sql.ErrNoRows, err)
+	books, err := s.Books.Search("test book 11")
+	assert.NoError(t, err)
+	assert.Empty(t, books)
+	count, err := s.Books.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestBookRepository_Patch(t *testing.T) {
+	name := "patched name"
+	coast := uint(0)
+	genre := int64(99)
+
+	testCases := []struct {
+		name    string
+		version int64
+		patch   *models.BookPatch
+		err     bool
+	}{
+		{
+			name:    "valid patch",
+			version: 1,
+			patch:   &models.BookPatch{Name: &name},
+		},
+		{
+			name:    "stale version",
+			version: 1,
+			patch:   &models.BookPatch{Name: &name},
+			err:     true,
+		},
+		{
+			name:    "invalid result",
+			version: 2,
+			patch:   &models.BookPatch{Coast: &coast},
+			err:     true,
+		},
+		{
+			name:    "foreign key violation",
+			version: 2,
+			patch:   &models.BookPatch{GenreId: &genre},
+			err:     true,
+		},
+	}
+
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	before, err := br.GetById(1)
+	assert.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			book, err := br.Patch(1, tc.version, tc.patch)
+			if tc.err {
+				assert.Error(t, err)
+				assert.Nil(t, book)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, name, book.Name)
+				assert.Equal(t, before.Coast, book.Coast)
+				assert.Equal(t, before.PosterURL, book.PosterURL)
+			}
+		})
+	}
+
+	_, err = br.Patch(1, 2, &models.BookPatch{Coast: &coast})
+	var fieldErrs models.ValidationErrors
+	assert.True(t, errors.As(err, &fieldErrs))
+	assert.Equal(t, "coast", fieldErrs[0].Field)
+
+	after, err := br.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, name, after.Name)
+	assert.Equal(t, before.Coast, after.Coast)
+	assert.Equal(t, int64(2), after.Version)
+}
+
+func TestBookRepository_AuthorName(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	book, err := s.Books.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Potter Harry", book.AuthorName)
+
+	books, err := s.WithNameFormat(models.NameFirstLast).Books.GetByAuthor(2, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Freddy Laurence", books[0].AuthorName)
+
+	author, err := s.Authors.Get(1)
+	assert.NoError(t, err)
+	author.PenName = "H. P."
+	assert.NoError(t, s.Authors.Update(author))
+
+	books, err = s.WithNameFormat(models.NameLastInitials).Books.Search("H. P.")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(books))
+	assert.Equal(t, "H. P.", books[0].AuthorName)
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// The repositories write their queries for SQLite: ? placeholders, LIKE
+// matching case-insensitively, char() for characters by code and
+// "LIMIT ? OFFSET ?" pagination. New rows report their id through
+// "RETURNING id", which both databases support. A dialect rewrites what
+// differs for the database behind a *sql.DB.
+type dialect struct {
+	name    string
+	rewrite func(query string) string
+}
+
+var (
+	sqliteDialect   = dialect{name: "sqlite3"}
+	postgresDialect = dialect{name: "postgres", rewrite: rewritePostgres}
+)
+
+// dialectOf returns the dialect of driver, a driver name as configured with
+// db.Config.Driver. Anything but PostgreSQL is taken for SQLite.
+func dialectOf(driver string) dialect {
+	if driver == postgresDialect.name {
+		return postgresDialect
+	}
+	return sqliteDialect
+}
+
+// rewritePostgres numbers the placeholders, matches LIKE with ILIKE and
+// renames char() to chr(). String literals are copied unchanged.
+func rewritePostgres(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+
+	params := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(query[i+1:], '\'')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 2
+		case c == '?':
+			params++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(params))
+			i++
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(query) && isIdentPart(query[j]) {
+				j++
+			}
+			word := query[i:j]
+			switch {
+			case strings.EqualFold(word, "LIKE"):
+				b.WriteString("ILIKE")
+			case strings.EqualFold(word, "char") && strings.HasPrefix(strings.TrimLeft(query[j:], " "), "("):
+				b.WriteString("chr")
+			default:
+				b.WriteString(word)
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// dialectQuerier runs the queries of a repository through the dialect
+// before handing them to q.
+type dialectQuerier struct {
+	q       querier
+	dialect dialect
+}
+
+func (d dialectQuerier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.q.Exec(d.dialect.rewrite(query), args...)
+}
+
+func (d dialectQuerier) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.q.Query(d.dialect.rewrite(query), args...)
+}
+
+func (d dialectQuerier) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.q.QueryRow(d.dialect.rewrite(query), args...)
+}
+
+func withDialect(q querier, d dialect) querier {
+	if d.rewrite == nil {
+		return q
+	}
+	return dialectQuerier{q: q, dialect: d}
+}
+
+// unbind returns the *sql.DB or *sql.Tx behind q together with its dialect.
+func unbind(q querier) (querier, dialect) {
+	if d, ok := q.(dialectQuerier); ok {
+		return d.q, d.dialect
+	}
+	return q, sqliteDialect
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"bookland/internal/db"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRewritePostgres(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "placeholders",
+			query: "UPDATE book SET name = ? WHERE id = ? AND version = ?",
+			want:  "UPDATE book SET name = $1 WHERE id = $2 AND version = $3",
+		},
+		{
+			name:  "pagination",
+			query: "SELECT id FROM book ORDER BY id DESC LIMIT ? OFFSET ?",
+			want:  "SELECT id FROM book ORDER BY id DESC LIMIT $1 OFFSET $2",
+		},
+		{
+			name:  "like",
+			query: "SELECT id FROM genre WHERE name LIKE ? OR name like ?",
+			want:  "SELECT id FROM genre WHERE name ILIKE $1 OR name ILIKE $2",
+		},
+		{
+			name:  "char",
+			query: "SELECT a.last_name || char(31) || a.first_name, a.char_count FROM author a",
+			want:  "SELECT a.last_name || chr(31) || a.first_name, a.char_count FROM author a",
+		},
+		{
+			name:  "string literals",
+			query: "SELECT 'who? LIKE char(1)', 'it''s' FROM book WHERE name = ?",
+			want:  "SELECT 'who? LIKE char(1)', 'it''s' FROM book WHERE name = $1",
+		},
+		{
+			name:  "identifiers containing keywords",
+			query: "SELECT unlike, likes FROM t WHERE x = ?",
+			want:  "SELECT unlike, likes FROM t WHERE x = $1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rewritePostgres(tc.query))
+		})
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	conn := db.NewTestSQLiteDB(t)
+	defer conn.Close()
+
+	assert.Equal(t, sqliteDialect.name, dialectOf(db.DriverSQLite).name)
+	assert.Equal(t, postgresDialect.name, dialectOf(db.DriverPostgres).name)
+
+	// SQLite runs the queries as written.
+	assert.Equal(t, conn, withDialect(conn, sqliteDialect))
+
+	pg := withDialect(conn, postgresDialect)
+	assert.IsType(t, dialectQuerier{}, pg)
+	q, d := unbind(pg)
+	assert.Equal(t, conn, q)
+	assert.Equal(t, postgresDialect.name, d.name)
+}
+
+// NewTestDB returns a database for the test alone holding the data the tests
+// share, as fixture.NewTestDB does. The fixture package imports store, so
+// only the external test package can import it; fixture_test.go sets this.
+var NewTestDB func(t *testing.T) *sql.DB
+
+// testDB binds a database from NewTestDB to the dialect of its driver,
+// as NewStoreFor does.
+func testDB(conn *sql.DB) querier {
+	return withDialect(conn, dialectOf(db.TestDriver()))
+}
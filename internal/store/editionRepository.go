@@ -0,0 +1,217 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"database/sql"
+)
+
+const editionSelect = "SELECT id, book_id, format, COALESCE(isbn, ''), released, coast, pages, poster FROM edition"
+
+func scanEdition(s scanner, e *models.Edition) error {
+	return s.Scan(&e.Id, &e.BookId, &e.Format, &e.ISBN, &e.Release, &e.Coast, &e.Pages, &e.PosterURL)
+}
+
+// EditionRepository stores the editions of books. Writes that change a
+// book's primary edition, its first one, update the book to match through
+// Books.Update, so the book is versioned, audited and kept in its history.
+type EditionRepository struct {
+	db    querier
+	books *bookRepository
+}
+
+func newEditionRepository(db querier) *EditionRepository {
+	return &EditionRepository{db: db, books: newBookRepository(db)}
+}
+
+// with returns the repository bound to q, keeping the book repository's
+// audit actor.
+func (er *EditionRepository) with(q querier) *EditionRepository {
+	return &EditionRepository{db: q, books: er.books.with(q)}
+}
+
+func (er *EditionRepository) Get(id int) (*models.Edition, error) {
+	e := &models.Edition{}
+	if err := scanEdition(er.db.QueryRow(editionSelect+" WHERE id = ?", id), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetByBook returns the editions of a book ordered by release date.
+func (er *EditionRepository) GetByBook(idBook int) ([]models.Edition, error) {
+	rows, err := er.db.Query(editionSelect+" WHERE book_id = ? ORDER BY released, id", idBook)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var editions []models.Edition
+	for rows.Next() {
+		var e models.Edition
+		if err := scanEdition(rows, &e); err != nil {
+			return nil, err
+		}
+		editions = append(editions, e)
+	}
+	return editions, rows.Err()
+}
+
+// primary returns the book's primary edition, the first one added.
+func (er *EditionRepository) primary(idBook int64) (*models.Edition, error) {
+	e := &models.Edition{}
+	if err := scanEdition(er.db.QueryRow(editionSelect+" WHERE book_id = ? ORDER BY id LIMIT 1", idBook), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Add inserts the edition. An invalid edition is rejected with
+// models.ValidationErrors and an ISBN another edition has with ErrISBNInUse.
+func (er *EditionRepository) Add(e *models.Edition) error {
+	return inTx(er.db, func(q querier) error {
+		repo := er.with(q)
+		if err := repo.insert(e); err != nil {
+			return err
+		}
+		return repo.syncBook(e.BookId)
+	})
+}
+
+// Update saves the edition. It returns sql.ErrNoRows when there is no such
+// edition; its book cannot be changed.
+func (er *EditionRepository) Update(e *models.Edition) error {
+	return inTx(er.db, func(q querier) error {
+		repo := er.with(q)
+		if err := q.QueryRow("SELECT book_id FROM edition WHERE id = ?", e.Id).Scan(&e.BookId); err != nil {
+			return err
+		}
+		if err := repo.update(e); err != nil {
+			return err
+		}
+		return repo.syncBook(e.BookId)
+	})
+}
+
+// Delete removes the edition. It returns sql.ErrNoRows when there is no such
+// edition and ErrLastEdition when it is the only one of its book. When the
+// primary edition is removed, the next one added becomes primary.
+func (er *EditionRepository) Delete(id int) error {
+	return inTx(er.db, func(q querier) error {
+		repo := er.with(q)
+		e, err := repo.Get(id)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err := q.QueryRow("SELECT COUNT(id) FROM edition WHERE book_id = ?", e.BookId).Scan(&count); err != nil {
+			return err
+		}
+		if count == 1 {
+			return ErrLastEdition
+		}
+
+		res, err := q.Exec("DELETE FROM edition WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+		return repo.syncBook(e.BookId)
+	})
+}
+
+// insert validates and inserts the edition without touching its book.
+func (er *EditionRepository) insert(e *models.Edition) error {
+	if err := normalizeEdition(e); err != nil {
+		return err
+	}
+	if err := checkISBN(er.db, e.ISBN, e.BookId, 0); err != nil {
+		return err
+	}
+
+	return er.db.QueryRow(
+		"INSERT INTO edition(book_id, format, isbn, released, coast, pages, poster) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id",
+		e.BookId, e.Format, nullString(e.ISBN), e.Release, e.Coast, e.Pages, e.PosterURL,
+	).Scan(&e.Id)
+}
+
+// update validates and saves the edition without touching its book.
+func (er *EditionRepository) update(e *models.Edition) error {
+	if err := normalizeEdition(e); err != nil {
+		return err
+	}
+	if err := checkISBN(er.db, e.ISBN, e.BookId, e.Id); err != nil {
+		return err
+	}
+
+	res, err := er.db.Exec(
+		"UPDATE edition SET format = ?, isbn = ?, released = ?, coast = ?, pages = ?, poster = ? WHERE id = ?",
+		e.Format, nullString(e.ISBN), e.Release, e.Coast, e.Pages, e.PosterURL, e.Id,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// trashEditions copies deleted_at from the books to their editions
+// matching where, so that the unique index on edition ISBNs, which skips
+// deleted rows, covers the editions of the books outside the trash.
+func trashEditions(q querier, where string, args ...interface{}) error {
+	_, err := q.Exec("UPDATE edition SET deleted_at = (SELECT b.deleted_at FROM book b WHERE b.id = edition.book_id) WHERE "+where, args...)
+	return err
+}
+
+// normalizeEdition validates e and stores its ISBN as digits only.
+func normalizeEdition(e *models.Edition) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	isbn, err := models.NormalizeISBN(e.ISBN)
+	if err != nil {
+		return err
+	}
+	e.ISBN = isbn
+	return nil
+}
+
+// syncBook updates the book to match its primary edition when they differ.
+func (er *EditionRepository) syncBook(idBook int64) error {
+	e, err := er.primary(idBook)
+	if err != nil {
+		return err
+	}
+	b, err := er.books.getRow(idBook)
+	if err != nil {
+		return err
+	}
+	b.Format = e.Format
+	if b.ISBN == e.ISBN && b.Release.Equal(e.Release) && b.Coast == e.Coast && b.Pages == e.Pages && b.PosterURL == e.PosterURL {
+		return nil
+	}
+
+	copyEdition(b, e)
+	return er.books.Update(b)
+}
+
+// syncPrimary copies the book's format, ISBN, release date, price, pages and
+// poster to its primary edition, creating it when the book has no editions.
+// A book without a format keeps its edition's.
+func (er *EditionRepository) syncPrimary(b *models.Book) error {
+	e := primaryEdition(b)
+	current, err := er.primary(b.Id)
+	if err == sql.ErrNoRows {
+		return er.insert(&e)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.Id = current.Id
+	if b.Format == "" {
+		e.Format = current.Format
+	}
+	return er.update(&e)
+}
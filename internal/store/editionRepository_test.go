@@ -0,0 +1,324 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEditionRepository_Add(t *testing.T) {
+	testCases := []struct {
+		name    string
+		edition models.Edition
+		valid   bool
+	}{
+		{
+			name: "valid edition",
+			edition: models.Edition{
+				BookId:    1,
+				Format:    models.FormatHardcover,
+				ISBN:      "978-0-306-40615-7",
+				Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+				Coast:     500,
+				Pages:     320,
+				PosterURL: "hardcover.png",
+			},
+			valid: true,
+		},
+		{
+			name: "invalid book",
+			edition: models.Edition{
+				BookId:  99,
+				Format:  models.FormatEbook,
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+				Coast:   100,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid format",
+			edition: models.Edition{
+				BookId:  1,
+				Format:  "bogus",
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+			},
+			valid: false,
+		},
+		{
+			name: "isbn of another edition",
+			edition: models.Edition{
+				BookId:  1,
+				Format:  models.FormatEbook,
+				ISBN:    "9780306406157",
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+				Coast:   100,
+			},
+			valid: false,
+		},
+		{
+			name: "invalid isbn",
+			edition: models.Edition{
+				BookId:  1,
+				Format:  models.FormatEbook,
+				ISBN:    "978-0-306-40615-8",
+				Release: time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+				Coast:   100,
+			},
+			valid: false,
+		},
+	}
+
+	conn := NewTestDB(t)
+	defer conn.Close()
+	er := newEditionRepository(testDB(conn))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := er.Add(&tc.edition)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.NotZero(t, tc.edition.Id)
+				assert.Equal(t, "9780306406157", tc.edition.ISBN)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestEditionRepository_UpdateDelete(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	er := newEditionRepository(testDB(conn))
+	br := newBookRepository(testDB(conn))
+
+	// Book 1 stands for a book stored before editions existed.
+	_, err := conn.Exec("DELETE FROM edition WHERE book_id = 1")
+	assert.NoError(t, err)
+
+	primary := &models.Edition{
+		BookId:    1,
+		Format:    models.FormatPaperback,
+		Release:   time.Date(2011, 5, 1, 0, 0, 0, 0, time.UTC),
+		Coast:     200,
+		Pages:     300,
+		PosterURL: "paperback.png",
+	}
+	assert.NoError(t, er.Add(primary))
+	second := &models.Edition{
+		BookId:    1,
+		Format:    models.FormatEbook,
+		ISBN:      "0-306-40615-2",
+		Release:   time.Date(2012, 5, 1, 0, 0, 0, 0, time.UTC),
+		Coast:     90,
+		PosterURL: "ebook.png",
+	}
+	assert.NoError(t, er.Add(second))
+
+	primary.Coast = 180
+	primary.Format = models.FormatHardcover
+	assert.NoError(t, er.Update(primary))
+
+	actual, err := er.Get(int(primary.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, primary, actual)
+
+	// The book follows its primary edition.
+	book, err := br.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(180), book.Coast)
+	assert.Equal(t, models.FormatHardcover, book.Format)
+
+	invalid := *primary
+	invalid.Coast = 0
+	assert.Error(t, er.Update(&invalid))
+	missing := *primary
+	missing.Id = 99
+	assert.Equal(t, sql.ErrNoRows, er.Update(&missing))
+	assert.Equal(t, sql.ErrNoRows, er.Delete(99))
+
+	// Deleting the primary edition makes the next one primary.
+	assert.NoError(t, er.Delete(int(primary.Id)))
+	actual, err = er.Get(int(primary.Id))
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+
+	book, err = br.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "9780306406157", book.ISBN)
+	assert.Equal(t, uint(90), book.Coast)
+	assert.Equal(t, uint(0), book.Pages)
+	assert.Equal(t, models.FormatEbook, book.Format)
+
+	assert.Equal(t, ErrLastEdition, er.Delete(int(second.Id)))
+}
+
+func TestBookRepository_GetByIdWithEditions(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	book := &models.Book{
+		Name:      "Book with editions",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     500,
+		Pages:     320,
+		PosterURL: "hardcover.png",
+		AuthorId:  1,
+		GenreId:   1,
+		Editions: []models.Edition{
+			{
+				Format:    models.FormatHardcover,
+				Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+				Coast:     500,
+				Pages:     320,
+				PosterURL: "hardcover.png",
+			},
+			{
+				Format:    models.FormatEbook,
+				ISBN:      "0-306-40615-2",
+				Release:   time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC),
+				Coast:     150,
+				PosterURL: "ebook.png",
+			},
+		},
+	}
+	assert.NoError(t, br.Add(book))
+
+	actual, err := br.GetById(int(book.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(actual.Editions))
+	assert.Equal(t, models.FormatHardcover, actual.Editions[0].Format)
+	assert.Equal(t, models.FormatEbook, actual.Editions[1].Format)
+	assert.Equal(t, "9780306406157", actual.Editions[1].ISBN)
+
+	// A failing edition rolls back the whole book.
+	count, err := br.Count()
+	assert.NoError(t, err)
+	invalid := &models.Book{
+		Name:     "Book with duplicate edition isbn",
+		AuthorId: 1,
+		GenreId:  1,
+		Editions: []models.Edition{
+			{
+				Format:    models.FormatPaperback,
+				ISBN:      "978-1-4028-9462-6",
+				Release:   time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC),
+				Coast:     300,
+				Pages:     200,
+				PosterURL: "paperback.png",
+			},
+			{
+				Format:    models.FormatEbook,
+				ISBN:      "9780306406157",
+				Release:   time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC),
+				Coast:     100,
+				PosterURL: "ebook.png",
+			},
+		},
+	}
+	assert.Equal(t, ErrISBNInUse, br.Add(invalid))
+	countAfter, err := br.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, count, countAfter)
+	_, err = br.GetByISBN("978-1-4028-9462-6")
+	assert.Equal(t, sql.ErrNoRows, err)
+	var editions int
+	assert.NoError(t, conn.QueryRow("SELECT COUNT(id) FROM edition WHERE isbn = '9781402894626'").Scan(&editions))
+	assert.Equal(t, 0, editions)
+
+	// Editions of one book cannot share an ISBN either.
+	twice := &models.Book{
+		Name:     "Book with one isbn twice",
+		AuthorId: 1,
+		GenreId:  1,
+		Editions: []models.Edition{invalid.Editions[0], invalid.Editions[0]},
+	}
+	twice.Editions[1].Format = models.FormatHardcover
+	assert.Equal(t, ErrISBNInUse, br.Add(twice))
+
+	// A book is found by the ISBN of any of its editions.
+	found, err := br.GetByISBN("0-306-40615-2")
+	assert.NoError(t, err)
+	assert.Equal(t, book.Id, found.Id)
+}
+
+func TestBookRepository_EbookWithoutPages(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	book := &models.Book{
+		Name:     "Ebook only",
+		AuthorId: 1,
+		GenreId:  1,
+		Editions: []models.Edition{{
+			Format:    models.FormatEbook,
+			Release:   time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+			Coast:     100,
+			PosterURL: "ebook.png",
+		}},
+	}
+	assert.NoError(t, br.Add(book))
+
+	actual, err := br.GetById(int(book.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, models.FormatEbook, actual.Format)
+	assert.Equal(t, uint(0), actual.Pages)
+
+	// An update without a format keeps the edition's.
+	actual.Format = ""
+	actual.Coast = 120
+	assert.NoError(t, br.Update(actual))
+	assert.Equal(t, models.FormatEbook, actual.Format)
+}
+
+func TestBookRepository_PrimaryEdition(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	br := newBookRepository(testDB(conn))
+
+	book := &models.Book{
+		Name:      "Book without editions",
+		ISBN:      "978-0-306-40615-7",
+		Release:   time.Date(2010, 10, 10, 0, 0, 0, 0, time.UTC),
+		Coast:     500,
+		Pages:     320,
+		PosterURL: "poster.png",
+		AuthorId:  1,
+		GenreId:   1,
+	}
+	assert.NoError(t, br.Add(book))
+
+	actual, err := br.GetById(int(book.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Edition{primaryEdition(book)}, withoutIds(actual.Editions))
+
+	book.Coast = 450
+	book.ISBN = ""
+	assert.NoError(t, br.Update(book))
+	actual, err = br.GetById(int(book.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Edition{primaryEdition(book)}, withoutIds(actual.Editions))
+
+	// Books stored before editions existed get their primary edition on update.
+	_, err = conn.Exec("DELETE FROM edition WHERE book_id = 2")
+	assert.NoError(t, err)
+	legacy, err := br.GetById(2)
+	assert.NoError(t, err)
+	assert.Empty(t, legacy.Editions)
+	legacy.Release = time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, br.Update(legacy))
+	actual, err = br.GetById(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Edition{primaryEdition(legacy)}, withoutIds(actual.Editions))
+}
+
+func withoutIds(editions []models.Edition) []models.Edition {
+	for i := range editions {
+		editions[i].Id = 0
+	}
+	return editions
+}
@@ -0,0 +1,96 @@
+package store_test
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/fixture"
+	"bookland/internal/models"
+	"bookland/internal/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// The tests in this file run the repositories against fixture sets. They
+// live in package store_test, since the fixture package imports store.
+
+func init() {
+	store.NewTestDB = fixture.NewTestDB
+}
+
+func newFixtureStore(t *testing.T) *store.Store {
+	conn := db.NewTestDB(t)
+	t.Cleanup(func() { conn.Close() })
+	return store.NewStoreFor(conn, db.TestDriver()).WithNameFormat(models.NameFirstLast)
+}
+
+func TestStore_Classics(t *testing.T) {
+	s := newFixtureStore(t)
+	res := fixture.LoadFiles(t, s, fixture.Path("classics.yaml"))
+
+	book, err := s.Books.GetByISBN("978-0-547-92822-7")
+	assert.NoError(t, err)
+	assert.Equal(t, "The Hobbit", book.Name)
+	assert.Equal(t, "John Ronald Tolkien", book.AuthorName)
+
+	// A pen name is displayed instead of the legal name and can be searched.
+	books, err := s.Books.Search("orwell")
+	assert.NoError(t, err)
+	var names []string
+	for _, b := range books {
+		assert.Equal(t, "George Orwell", b.AuthorName)
+		names = append(names, b.Name)
+	}
+	assert.ElementsMatch(t, []string{"Nineteen Eighty-Four", "Animal Farm"}, names)
+
+	books, err = s.Books.GetByGenre(int(res.Genres["Science fiction"].Id), 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(books))
+
+	leGuin := res.Authors["le-guin"]
+	books, err = s.Books.GetByAuthor(int(leGuin.Id), 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(books))
+	assert.Equal(t, "A Wizard of Earthsea", books[0].Name)
+
+	author, err := s.Authors.GetBySlug(leGuin.Slug)
+	assert.NoError(t, err)
+	assert.Equal(t, "US", author.Nationality)
+	assert.NotNil(t, author.DeathDay)
+
+	// Deleting an author hides their books.
+	assert.NoError(t, s.Authors.Delete(int(leGuin.Id)))
+	books, err = s.Books.GetByGenre(int(res.Genres["Science fiction"].Id), 10, 1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(books)) {
+		assert.Equal(t, "Dune", books[0].Name)
+	}
+}
+
+func TestStore_GeneratedCatalogue(t *testing.T) {
+	s := newFixtureStore(t)
+	res := fixture.LoadGenerated(t, s, fixture.GenerateOptions{Books: 120, Authors: 12, Seed: 3})
+
+	count, err := s.Books.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, len(res.Books), count)
+
+	// Paging through every book sees each one exactly once.
+	seen := map[int64]bool{}
+	for page := 1; ; page++ {
+		books, err := s.Books.GetPerPage(25, page)
+		assert.NoError(t, err)
+		if len(books) == 0 {
+			break
+		}
+		for _, b := range books {
+			assert.False(t, seen[b.Id], b.Id)
+			seen[b.Id] = true
+		}
+	}
+	assert.Equal(t, count, len(seen))
+
+	for _, b := range res.Books[:10] {
+		actual, err := s.Books.GetByISBN(b.ISBN)
+		assert.NoError(t, err)
+		assert.Equal(t, b.Id, actual.Id)
+	}
+}
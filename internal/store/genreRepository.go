@@ -0,0 +1,152 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"database/sql"
+	"time"
+)
+
+const genreSelect = "SELECT id, name, version, updated_at FROM genre"
+
+func scanGenre(s scanner, g *models.Genre) error {
+	return s.Scan(&g.Id, &g.Name, &g.Version, nullTime{&g.UpdatedAt})
+}
+
+type GenreRepository struct {
+	db    querier
+	audit auditor
+}
+
+func newGenreRepository(db querier) *GenreRepository {
+	return &GenreRepository{db: db}
+}
+
+// with returns the repository bound to q, keeping the audit actor.
+func (gr *GenreRepository) with(q querier) *GenreRepository {
+	return &GenreRepository{db: q, audit: gr.audit}
+}
+
+func (gr *GenreRepository) Get(id int) (*models.Genre, error) {
+	genre := &models.Genre{}
+	if err := scanGenre(gr.db.QueryRow(genreSelect+" WHERE id = ?", id), genre); err != nil {
+		return nil, err
+	}
+	return genre, nil
+}
+
+func (gr *GenreRepository) GetByName(name string) (*models.Genre, error) {
+	genre := &models.Genre{}
+	if err := scanGenre(gr.db.QueryRow(genreSelect+" WHERE name = ?", name), genre); err != nil {
+		return nil, err
+	}
+	return genre, nil
+}
+
+func (gr *GenreRepository) Add(genre *models.Genre) error {
+	genre.Normalize()
+	if err := genre.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return inTx(gr.db, func(q querier) error {
+		err := q.QueryRow(
+			"INSERT INTO genre(name, version, updated_at) VALUES (?, 1, ?) RETURNING id", genre.Name, now,
+		).Scan(&genre.Id)
+		if err != nil {
+			return err
+		}
+		genre.Version = 1
+		genre.UpdatedAt = now
+
+		return gr.audit.record(q, "genre", genre.Id, models.AuditCreate, nil, genreSnapshot(genre))
+	})
+}
+
+// Update saves the genre if it still has the version the caller read and
+// returns ErrConflict otherwise. On success genre.Version is incremented.
+func (gr *GenreRepository) Update(genre *models.Genre) error {
+	genre.Normalize()
+	if err := genre.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return inTx(gr.db, func(q querier) error {
+		before, err := gr.with(q).Get(int(genre.Id))
+		if err != nil {
+			return err
+		}
+
+		res, err := q.Exec(
+			"UPDATE genre SET name = ?, version = version + 1, updated_at = ? WHERE id = ? AND version = ?",
+			genre.Name, now, genre.Id, genre.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(q, res, "genre", genre.Id); err != nil {
+			return err
+		}
+
+		genre.Version++
+		genre.UpdatedAt = now
+		return gr.audit.record(q, "genre", genre.Id, models.AuditUpdate, genreSnapshot(before), genreSnapshot(genre))
+	})
+}
+
+// Delete removes the genre for good. Genres have no trash; a genre that any
+// book refers to, including books in the trash, is kept and ErrGenreInUse
+// returned.
+func (gr *GenreRepository) Delete(id int) error {
+	return inTx(gr.db, func(q querier) error {
+		before, err := gr.with(q).Get(id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var books int
+		if err := q.QueryRow("SELECT COUNT(id) FROM book WHERE genre_id = ?", id).Scan(&books); err != nil {
+			return err
+		}
+		if books > 0 {
+			return ErrGenreInUse
+		}
+
+		if _, err := q.Exec("DELETE FROM genre WHERE id = ?", id); err != nil {
+			return err
+		}
+		return gr.audit.record(q, "genre", before.Id, models.AuditDelete, genreSnapshot(before), nil)
+	})
+}
+
+func (gr *GenreRepository) GetAll() ([]models.Genre, error) {
+	return gr.query(genreSelect + " ORDER BY name")
+}
+
+// Search matches genres by name.
+func (gr *GenreRepository) Search(value string) ([]models.Genre, error) {
+	return gr.query(genreSelect+" WHERE name LIKE ? ORDER BY name", "%"+value+"%")
+}
+
+func (gr *GenreRepository) query(query string, args ...interface{}) ([]models.Genre, error) {
+	rows, err := gr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var genres []models.Genre
+	for rows.Next() {
+		var g models.Genre
+		if err := scanGenre(rows, &g); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+
+	return genres, rows.Err()
+}
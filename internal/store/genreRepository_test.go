@@ -0,0 +1,181 @@
+package store
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/models"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestGenreRepository_Get(t *testing.T) {
+	testCases := []struct {
+		name  string
+		id    int
+		valid bool
+	}{
+		{
+			name:  "valid id genre",
+			id:    1,
+			valid: true,
+		},
+		{
+			name:  "invalid id genre",
+			id:    99,
+			valid: false,
+		},
+	}
+
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			genre, err := gr.Get(tc.id)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.NotNil(t, genre)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, genre)
+			}
+		})
+	}
+}
+
+func TestGenreRepository_GetByName(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	genre, err := gr.GetByName("test_genre 2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), genre.Id)
+
+	genre, err = gr.GetByName("unknown genre")
+	assert.Error(t, err)
+	assert.Nil(t, genre)
+}
+
+func TestGenreRepository_Add(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	genre := &models.Genre{Name: "Fantasy"}
+	err := gr.Add(genre)
+	assert.NoError(t, err)
+	assert.NotZero(t, genre.Id)
+
+	actual, err := gr.Get(int(genre.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, genre, actual)
+}
+
+func TestGenreRepository_AddInvalid(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	testCases := []struct {
+		name  string
+		genre *models.Genre
+		code  string
+	}{
+		{name: "empty name", genre: &models.Genre{Name: " \t"}, code: models.CodeRequired},
+		{name: "long name", genre: &models.Genre{Name: strings.Repeat("g", models.MaxNameLength+1)}, code: models.CodeTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var fieldErrs models.ValidationErrors
+			assert.True(t, errors.As(gr.Add(tc.genre), &fieldErrs))
+			assert.Equal(t, 1, len(fieldErrs))
+			assert.Equal(t, "name", fieldErrs[0].Field)
+			assert.Equal(t, tc.code, fieldErrs[0].Code)
+		})
+	}
+
+	genre := &models.Genre{Name: "  Poetry "}
+	assert.NoError(t, gr.Add(genre))
+	assert.Equal(t, "Poetry", genre.Name)
+}
+
+func TestGenreRepository_GetAll(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	genres, err := gr.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(genres))
+}
+
+func TestGenreRepository_Update(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	genre, err := gr.Get(1)
+	assert.NoError(t, err)
+	stale := *genre
+
+	genre.Name = "Science fiction"
+	assert.NoError(t, gr.Update(genre))
+	assert.Equal(t, int64(2), genre.Version)
+
+	actual, err := gr.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, genre, actual)
+
+	stale.Name = "Fantasy"
+	assert.Equal(t, ErrConflict, gr.Update(&stale))
+}
+
+func TestGenreRepository_Search(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	gr := newGenreRepository(testDB(conn))
+
+	genres, err := gr.Search("GENRE 2")
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(genres)) {
+		assert.Equal(t, int64(2), genres[0].Id)
+	}
+
+	genres, err = gr.Search("poetry")
+	assert.NoError(t, err)
+	assert.Empty(t, genres)
+}
+
+func TestGenreRepository_Delete(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver()).WithActor("admin")
+
+	assert.Equal(t, ErrGenreInUse, s.Genres.Delete(1))
+	_, err := s.Genres.Get(1)
+	assert.NoError(t, err)
+
+	// Books in the trash still hold on to their genre.
+	assert.NoError(t, s.Authors.Delete(2))
+	assert.Equal(t, ErrGenreInUse, s.Genres.Delete(2))
+
+	genre := &models.Genre{Name: "Poetry"}
+	assert.NoError(t, s.Genres.Add(genre))
+	assert.NoError(t, s.Genres.Delete(int(genre.Id)))
+	_, err = s.Genres.Get(int(genre.Id))
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	entries, err := s.Audit.History("genre", genre.Id, 10, 1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, models.AuditDelete, entries[0].Action)
+		assert.Equal(t, "admin", entries[0].Actor)
+	}
+
+	assert.NoError(t, s.Genres.Delete(99))
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"database/sql"
+	"errors"
+)
+
+var ErrInvalidPosition = errors.New("series position must be positive")
+
+type SeriesRepository struct {
+	db    querier
+	names models.NameFormat
+}
+
+func newSeriesRepository(db querier) *SeriesRepository {
+	return &SeriesRepository{db: db}
+}
+
+// Add inserts the series. An invalid series is rejected with
+// models.ValidationErrors.
+func (sr *SeriesRepository) Add(s *models.Series) error {
+	s.Normalize()
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	return sr.db.QueryRow(
+		"INSERT INTO series(name, description) VALUES (?, ?) RETURNING id", s.Name, s.Description,
+	).Scan(&s.Id)
+}
+
+// Get returns the series with its volumes ordered by position.
+func (sr *SeriesRepository) Get(id int) (*models.Series, error) {
+	s := &models.Series{}
+	err := sr.db.QueryRow("SELECT id, name, description FROM series WHERE id = ?", id).Scan(&s.Id, &s.Name, &s.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sr.db.Query(
+		"SELECT sb.position, "+bookColumns+" "+bookFrom+" INNER JOIN series_book sb ON sb.book_id = b.id WHERE sb.series_id = ? AND b.deleted_at IS NULL AND a.deleted_at IS NULL ORDER BY sb.position",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.SeriesBook
+		if err := rows.Scan(append([]interface{}{&v.Position}, bookFields(&v.Book, sr.names)...)...); err != nil {
+			return nil, err
+		}
+		s.Volumes = append(s.Volumes, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Update saves the series. An invalid series is rejected with
+// models.ValidationErrors.
+func (sr *SeriesRepository) Update(s *models.Series) error {
+	s.Normalize()
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	_, err := sr.db.Exec("UPDATE series SET name = ?, description = ? WHERE id = ?", s.Name, s.Description, s.Id)
+	return err
+}
+
+func (sr *SeriesRepository) Delete(id int) error {
+	_, err := sr.db.Exec("DELETE FROM series WHERE id = ?", id)
+	return err
+}
+
+// AddBook puts a book at position in a series, moving it if it is already a member.
+func (sr *SeriesRepository) AddBook(idSeries, idBook int, position float64) error {
+	if position <= 0 {
+		return ErrInvalidPosition
+	}
+	_, err := sr.db.Exec(
+		`INSERT INTO series_book(series_id, book_id, position) VALUES (?, ?, ?)
+		ON CONFLICT(series_id, book_id) DO UPDATE SET position = excluded.position`,
+		idSeries, idBook, position,
+	)
+	return err
+}
+
+func (sr *SeriesRepository) RemoveBook(idSeries, idBook int) error {
+	_, err := sr.db.Exec("DELETE FROM series_book WHERE series_id = ? AND book_id = ?", idSeries, idBook)
+	return err
+}
+
+// GetByBook returns every series the book belongs to, with the ids of the
+// previous and next volumes in each.
+func (sr *SeriesRepository) GetByBook(idBook int) ([]models.SeriesVolume, error) {
+	rows, err := sr.db.Query(
+		`SELECT s.id, s.name, sb.position,
+		(SELECT p.book_id FROM series_book p INNER JOIN book pb ON pb.id = p.book_id
+			WHERE p.series_id = sb.series_id AND p.position < sb.position AND pb.deleted_at IS NULL ORDER BY p.position DESC LIMIT 1),
+		(SELECT n.book_id FROM series_book n INNER JOIN book nb ON nb.id = n.book_id
+			WHERE n.series_id = sb.series_id AND n.position > sb.position AND nb.deleted_at IS NULL ORDER BY n.position LIMIT 1)
+		FROM series_book sb INNER JOIN series s ON s.id = sb.series_id
+		WHERE sb.book_id = ? ORDER BY s.name`,
+		idBook,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var volumes []models.SeriesVolume
+	for rows.Next() {
+		var v models.SeriesVolume
+		var prev, next sql.NullInt64
+		if err := rows.Scan(&v.SeriesId, &v.SeriesName, &v.Position, &prev, &next); err != nil {
+			return nil, err
+		}
+		if prev.Valid {
+			v.PrevBookId = &prev.Int64
+		}
+		if next.Valid {
+			v.NextBookId = &next.Int64
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, rows.Err()
+}
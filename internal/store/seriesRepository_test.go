@@ -0,0 +1,157 @@
+package store
+
+import (
+	"bookland/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeriesRepository_Get(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	sr := newSeriesRepository(testDB(conn))
+
+	series := &models.Series{Name: "The Expanse", Description: "Space opera"}
+	assert.NoError(t, sr.Add(series))
+	assert.NotZero(t, series.Id)
+
+	id := int(series.Id)
+	assert.NoError(t, sr.AddBook(id, 3, 3))
+	assert.NoError(t, sr.AddBook(id, 1, 1))
+	assert.NoError(t, sr.AddBook(id, 4, 2.5))
+	assert.NoError(t, sr.AddBook(id, 2, 2))
+
+	actual, err := sr.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "The Expanse", actual.Name)
+
+	var positions []float64
+	var ids []int64
+	for _, v := range actual.Volumes {
+		positions = append(positions, v.Position)
+		ids = append(ids, v.Book.Id)
+	}
+	assert.Equal(t, []float64{1, 2, 2.5, 3}, positions)
+	assert.Equal(t, []int64{1, 2, 4, 3}, ids)
+
+	actual, err = sr.Get(99)
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestSeriesRepository_Validation(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	sr := newSeriesRepository(testDB(conn))
+
+	required := models.ValidationErrors{{Field: "name", Code: models.CodeRequired, Message: "Series name is require field"}}
+	assert.Equal(t, required, sr.Add(&models.Series{Name: " "}))
+
+	series := &models.Series{Name: " The Expanse "}
+	assert.NoError(t, sr.Add(series))
+	assert.Equal(t, "The Expanse", series.Name)
+
+	series.Name = ""
+	assert.Equal(t, required, sr.Update(series))
+	actual, err := sr.Get(int(series.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, "The Expanse", actual.Name)
+}
+
+func TestSeriesRepository_AddBook(t *testing.T) {
+	testCases := []struct {
+		name     string
+		idBook   int
+		position float64
+		valid    bool
+	}{
+		{
+			name:     "valid position",
+			idBook:   1,
+			position: 1,
+			valid:    true,
+		},
+		{
+			name:     "move book to a new position",
+			idBook:   1,
+			position: 1.5,
+			valid:    true,
+		},
+		{
+			name:     "position taken",
+			idBook:   2,
+			position: 1.5,
+			valid:    false,
+		},
+		{
+			name:     "invalid position",
+			idBook:   2,
+			position: 0,
+			valid:    false,
+		},
+		{
+			name:     "invalid book",
+			idBook:   99,
+			position: 4,
+			valid:    false,
+		},
+	}
+
+	conn := NewTestDB(t)
+	defer conn.Close()
+	sr := newSeriesRepository(testDB(conn))
+
+	series := &models.Series{Name: "Discworld"}
+	assert.NoError(t, sr.Add(series))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sr.AddBook(int(series.Id), tc.idBook, tc.position)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSeriesRepository_GetByBook(t *testing.T) {
+	conn := NewTestDB(t)
+	defer conn.Close()
+	sr := newSeriesRepository(testDB(conn))
+	br := newBookRepository(testDB(conn))
+
+	series := &models.Series{Name: "The Expanse"}
+	assert.NoError(t, sr.Add(series))
+	id := int(series.Id)
+	assert.NoError(t, sr.AddBook(id, 1, 1))
+	assert.NoError(t, sr.AddBook(id, 2, 2))
+	assert.NoError(t, sr.AddBook(id, 3, 2.5))
+
+	first, err := br.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(first.Series))
+	assert.Nil(t, first.Series[0].PrevBookId)
+	assert.Equal(t, int64(2), *first.Series[0].NextBookId)
+
+	middle, err := br.GetById(2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), *middle.Series[0].PrevBookId)
+	assert.Equal(t, int64(3), *middle.Series[0].NextBookId)
+
+	last, err := br.GetById(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, last.Series[0].Position)
+	assert.Equal(t, int64(2), *last.Series[0].PrevBookId)
+	assert.Nil(t, last.Series[0].NextBookId)
+
+	assert.NoError(t, sr.RemoveBook(id, 2))
+	last, err = br.GetById(3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), *last.Series[0].PrevBookId)
+
+	other, err := br.GetById(4)
+	assert.NoError(t, err)
+	assert.Empty(t, other.Series)
+}
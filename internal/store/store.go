@@ -1,13 +1,312 @@
 package store
 
-import "database/sql"
+import (
+	"bookland/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
 
-type store struct {
-	Books *bookRepository
+// ErrConflict is returned by Update when the row was changed by someone else
+// since the caller read it.
+var ErrConflict = errors.New("version conflict")
+
+// ErrAuthorDeleted is returned when adding, updating or restoring a book
+// whose author is in the trash.
+var ErrAuthorDeleted = errors.New("author is deleted")
+
+// ErrISBNInUse is returned when a book or edition gets an ISBN that a book
+// outside the trash or one of its editions already has.
+var ErrISBNInUse = errors.New("isbn is used by another book")
+
+// ErrGenreInUse is returned when deleting a genre that books still refer to.
+var ErrGenreInUse = errors.New("genre is in use")
+
+// ErrLastEdition is returned when deleting the only edition of a book.
+var ErrLastEdition = errors.New("book has no other edition")
+
+// querier is the subset of *sql.DB and *sql.Tx used by the repositories,
+// so the same repository code can run inside or outside a transaction.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullTime scans a nullable DATETIME column, leaving the time zero for NULL.
+type nullTime struct {
+	t *time.Time
+}
+
+func (n nullTime) Scan(value interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*n.t = nt.Time
+	return nil
+}
+
+// nullTimePtr scans a nullable DATETIME column into a pointer that stays nil for NULL.
+type nullTimePtr struct {
+	t **time.Time
+}
+
+func (n nullTimePtr) Scan(value interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*n.t = nil
+	if nt.Valid {
+		*n.t = &nt.Time
+	}
+	return nil
+}
+
+// stringList scans a TEXT column holding a JSON array of strings, leaving
+// the list nil when it is empty.
+type stringList struct {
+	list *[]string
 }
 
-func NewStore(db *sql.DB) *store {
-	return &store{
-		Books: newBookRepository(db),
+func (l stringList) Scan(value interface{}) error {
+	var s sql.NullString
+	if err := s.Scan(value); err != nil {
+		return err
+	}
+
+	var list []string
+	if s.String != "" {
+		if err := json.Unmarshal([]byte(s.String), &list); err != nil {
+			return err
+		}
+	}
+	*l.list = nil
+	if len(list) > 0 {
+		*l.list = list
+	}
+	return nil
+}
+
+// stringListValue encodes list for a column scanned by stringList.
+func stringListValue(list []string) (string, error) {
+	if len(list) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
+
+// queryIds runs a query selecting a single id column and collects the ids.
+func queryIds(q querier, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// checkAffected returns sql.ErrNoRows when the statement changed no rows.
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// checkVersion inspects the result of a versioned UPDATE. No affected rows
+// means either the row is gone (sql.ErrNoRows) or its version moved on (ErrConflict).
+func checkVersion(q querier, res sql.Result, table string, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var count int
+	if err := q.QueryRow("SELECT COUNT(id) FROM "+table+" WHERE id = ?", id).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return ErrConflict
+}
+
+type Store struct {
+	db       *sql.DB
+	dialect  dialect
+	actor    string
+	names    models.NameFormat
+	Books    *bookRepository
+	Editions *EditionRepository
+	Authors  *AuthorRepository
+	Genres   *GenreRepository
+	Series   *SeriesRepository
+	Audit    *AuditRepository
+}
+
+// NewStore returns a Store for a SQLite database.
+func NewStore(db *sql.DB) *Store {
+	return NewStoreFor(db, sqliteDialect.name)
+}
+
+// NewStoreFor returns a Store for a database opened with driver, as named by
+// db.Config.Driver. The driver decides the SQL dialect of the queries.
+func NewStoreFor(db *sql.DB, driver string) *Store {
+	d := dialectOf(driver)
+	return newStore(db, d, withDialect(db, d), "", models.NameLastFirst)
+}
+
+// newStore returns a Store whose repositories run their queries on q, which
+// is db or a transaction of it, already bound to d.
+func newStore(db *sql.DB, d dialect, q querier, actor string, names models.NameFormat) *Store {
+	s := &Store{
+		db:       db,
+		dialect:  d,
+		actor:    actor,
+		names:    names,
+		Books:    newBookRepository(q),
+		Editions: newEditionRepository(q),
+		Authors:  newAuthorRepository(q),
+		Genres:   newGenreRepository(q),
+		Series:   newSeriesRepository(q),
+		Audit:    newAuditRepository(q),
+	}
+	s.Books.audit = auditor{actor: actor}
+	s.Authors.audit = auditor{actor: actor}
+	s.Genres.audit = auditor{actor: actor}
+	s.Editions.books = s.Books
+	s.Books.names = names
+	s.Series.names = names
+	return s
+}
+
+// WithActor returns a Store whose writes are recorded in the audit log as
+// made by actor.
+func (s *Store) WithActor(actor string) *Store {
+	return newStore(s.db, s.dialect, s.Books.db, actor, s.names)
+}
+
+// WithNameFormat returns a Store that renders the author names of books in format.
+func (s *Store) WithNameFormat(format models.NameFormat) *Store {
+	return newStore(s.db, s.dialect, s.Books.db, s.actor, format)
+}
+
+// inTx runs fn in a new transaction, or directly when q already is one,
+// so a repository method that issues several statements applies all or none.
+func inTx(q querier, fn func(q querier) error) error {
+	conn, d := unbind(q)
+	db, ok := conn.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollbackOnPanic(tx)
+	if err := fn(withDialect(tx, d)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// rollbackOnPanic rolls tx back when the function deferring it panics, so
+// the connection is not left holding the transaction, and panics again.
+func rollbackOnPanic(tx *sql.Tx) {
+	if p := recover(); p != nil {
+		_ = tx.Rollback()
+		panic(p)
+	}
+}
+
+// Purge permanently removes books and authors that have been in the trash
+// for longer than retention. Books go first, so an author is only removed
+// together with books that were due for removal themselves.
+func (s *Store) Purge(retention time.Duration) (books int, authors int, err error) {
+	err = s.Tx(func(tx *Store) error {
+		if books, err = tx.Books.Purge(retention); err != nil {
+			return err
+		}
+		authors, err = tx.Authors.Purge(retention)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return books, authors, nil
+}
+
+// Tx runs fn with a Store whose repositories share one transaction.
+// The transaction is committed when fn returns nil and rolled back when it
+// returns an error or panics.
+func (s *Store) Tx(fn func(tx *Store) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollbackOnPanic(tx)
+
+	if err := fn(newStore(s.db, s.dialect, withDialect(tx, s.dialect), s.actor, s.names)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Savepoint runs fn within a savepoint of the transaction s belongs to and
+// rolls back to it when fn returns an error, undoing fn's writes only. On
+// PostgreSQL a failed statement aborts the whole transaction otherwise. A
+// Store outside a transaction runs fn in a transaction of its own, as Tx.
+func (s *Store) Savepoint(fn func(tx *Store) error) error {
+	q := s.Books.db
+	if conn, _ := unbind(q); conn == s.db {
+		return s.Tx(fn)
+	}
+
+	if _, err := q.Exec("SAVEPOINT store_savepoint"); err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		if _, rbErr := q.Exec("ROLLBACK TO SAVEPOINT store_savepoint"); rbErr != nil {
+			return rbErr
+		}
+		if _, rbErr := q.Exec("RELEASE SAVEPOINT store_savepoint"); rbErr != nil {
+			return rbErr
+		}
+		return err
 	}
+	_, err := q.Exec("RELEASE SAVEPOINT store_savepoint")
+	return err
 }
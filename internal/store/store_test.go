@@ -0,0 +1,87 @@
+package store
+
+import (
+	"bookland/internal/db"
+	"bookland/internal/models"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStore_TxPanic(t *testing.T) {
+	conn := db.NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = s.Tx(func(tx *Store) error {
+			if err := tx.Genres.Add(&models.Genre{Name: "Horror"}); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, conn.Stats().InUse)
+
+	_, err := s.Genres.GetByName("Horror")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, s.Genres.Add(&models.Genre{Name: "Horror"}))
+}
+
+func TestInTxPanic(t *testing.T) {
+	conn := db.NewTestDB(t)
+	defer conn.Close()
+	q := testDB(conn)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = inTx(q, func(q querier) error {
+			if _, err := q.Exec("INSERT INTO genre (name) VALUES (?)", "Horror"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, conn.Stats().InUse)
+
+	_, err := newGenreRepository(q).GetByName("Horror")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestStore_Savepoint(t *testing.T) {
+	conn := db.NewTestDB(t)
+	defer conn.Close()
+	s := NewStoreFor(conn, db.TestDriver())
+
+	boom := errors.New("boom")
+	err := s.Tx(func(tx *Store) error {
+		err := tx.Savepoint(func(tx *Store) error {
+			if err := tx.Genres.Add(&models.Genre{Name: "Horror"}); err != nil {
+				return err
+			}
+			return boom
+		})
+		assert.Equal(t, boom, err)
+
+		// The transaction goes on after the failed savepoint.
+		return tx.Savepoint(func(tx *Store) error {
+			return tx.Genres.Add(&models.Genre{Name: "Fantasy"})
+		})
+	})
+	assert.NoError(t, err)
+
+	_, err = s.Genres.GetByName("Horror")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	_, err = s.Genres.GetByName("Fantasy")
+	assert.NoError(t, err)
+
+	// Outside a transaction it runs in one of its own.
+	assert.Equal(t, boom, s.Savepoint(func(tx *Store) error {
+		if err := tx.Genres.Add(&models.Genre{Name: "Horror"}); err != nil {
+			return err
+		}
+		return boom
+	}))
+	_, err = s.Genres.GetByName("Horror")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}